@@ -0,0 +1,150 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// smoothingFilter denoises a measurement series. The result may be shorter
+// than the input if the filter needs a full window to produce a value.
+type smoothingFilter func(series []float64) []float64
+
+const filterSeparator = ","
+const filterParamSeparator = ":"
+
+// parseFilters parses a pipeline such as "median:5,avg:3" into filters that
+// are applied in order.
+func parseFilters(spec string) ([]smoothingFilter, error) {
+	if spec == "" {
+		return nil, nil
+	}
+
+	var result []smoothingFilter
+	for _, s := range strings.Split(spec, filterSeparator) {
+		f, err := parseFilter(s)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, f)
+	}
+	return result, nil
+}
+
+func parseFilter(s string) (smoothingFilter, error) {
+	parts := strings.Split(s, filterParamSeparator)
+	if len(parts) != 2 {
+		return nil, fmt.Errorf("filter must have the form name:param %s", s)
+	}
+
+	name, param := parts[0], parts[1]
+	switch name {
+	case "avg":
+		size, err := parseFilterSize(param)
+		if err != nil {
+			return nil, err
+		}
+		return movingAverage(size), nil
+
+	case "median":
+		size, err := parseFilterSize(param)
+		if err != nil {
+			return nil, err
+		}
+		return movingMedian(size), nil
+
+	case "ema":
+		alpha, err := strconv.ParseFloat(param, 64)
+		if err != nil || alpha <= 0 || alpha > 1 {
+			return nil, fmt.Errorf("ema smoothing factor must be in (0, 1]: %s", param)
+		}
+		return exponentialMovingAverage(alpha), nil
+	}
+
+	return nil, fmt.Errorf("unknown filter %s", name)
+}
+
+func parseFilterSize(s string) (int, error) {
+	size, err := strconv.Atoi(s)
+	if err != nil || size < 1 {
+		return 0, fmt.Errorf("filter window size must be a positive integer: %s", s)
+	}
+	return size, nil
+}
+
+func applyFilters(series []float64, filters []smoothingFilter) []float64 {
+	for _, f := range filters {
+		series = f(series)
+	}
+	return series
+}
+
+func movingAverage(size int) smoothingFilter {
+	return func(series []float64) []float64 {
+		var result []float64
+		sum := 0.0
+		for i, v := range series {
+			sum += v
+			if i >= size {
+				sum -= series[i-size]
+			}
+			if i >= size-1 {
+				result = append(result, sum/float64(size))
+			}
+		}
+		return result
+	}
+}
+
+func movingMedian(size int) smoothingFilter {
+	return func(series []float64) []float64 {
+		var result []float64
+		window := make([]float64, size)
+		for i := size - 1; i < len(series); i++ {
+			copy(window, series[i-size+1:i+1])
+			sort.Float64s(window)
+			if size%2 == 1 {
+				result = append(result, window[size/2])
+			} else {
+				result = append(result, (window[size/2-1]+window[size/2])/2)
+			}
+		}
+		return result
+	}
+}
+
+func exponentialMovingAverage(alpha float64) smoothingFilter {
+	return func(series []float64) []float64 {
+		result := make([]float64, len(series))
+		for i, v := range series {
+			if i == 0 {
+				result[i] = v
+				continue
+			}
+			result[i] = alpha*v + (1-alpha)*result[i-1]
+		}
+		return result
+	}
+}
+
+func toSeries(measurements []int) []float64 {
+	result := make([]float64, len(measurements))
+	for i, m := range measurements {
+		result[i] = float64(m)
+	}
+	return result
+}
+
+// seriesIncreases is the counterpart of increases for filtered series.
+func seriesIncreases(series []float64, windowSize int) int {
+	result := 0
+
+	for i := windowSize; i < len(series); i++ {
+		if series[i] > series[i-windowSize] {
+			result++
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"math"
+	"testing"
+)
+
+var exampleMeasurements = []int{199, 200, 208, 210, 200, 207, 240, 269, 260, 263}
+
+func TestFilters(t *testing.T) {
+	tests := []struct {
+		spec      string
+		series    []float64
+		increases int
+	}{
+		{
+			spec:      "avg:3",
+			series:    []float64{607.0 / 3, 206, 206, 617.0 / 3, 647.0 / 3, 716.0 / 3, 769.0 / 3, 264},
+			increases: 5,
+		},
+		{
+			spec:      "median:3",
+			series:    []float64{200, 208, 208, 207, 207, 240, 260, 263},
+			increases: 4,
+		},
+		{
+			spec:      "ema:0.5",
+			series:    []float64{199, 199.5, 203.75, 206.875, 203.4375, 205.21875, 222.609375, 245.8046875, 252.90234375, 257.951171875},
+			increases: 8,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			filters, err := parseFilters(tt.spec)
+			if err != nil {
+				t.Fatalf("parseFilters(%q) failed: %v", tt.spec, err)
+			}
+
+			series := applyFilters(toSeries(exampleMeasurements), filters)
+			if len(series) != len(tt.series) {
+				t.Fatalf("got series %v, want %v", series, tt.series)
+			}
+			for i := range series {
+				if math.Abs(series[i]-tt.series[i]) > 1e-9 {
+					t.Fatalf("got series %v, want %v", series, tt.series)
+				}
+			}
+
+			if got := seriesIncreases(series, 1); got != tt.increases {
+				t.Errorf("got %d increases, want %d", got, tt.increases)
+			}
+		})
+	}
+}
+
+func TestParseFiltersErrors(t *testing.T) {
+	for _, spec := range []string{"ema:0", "avg:0", "foo:1", "avg3", "avg:3,ema:0"} {
+		if _, err := parseFilters(spec); err == nil {
+			t.Errorf("parseFilters(%q) succeeded, want an error", spec)
+		}
+	}
+}
@@ -2,13 +2,78 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
+	"io"
 	"os"
 	"strconv"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
 )
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	windowSize := flag.Int("window", 3, "number of measurements in a sliding window")
+	filterSpec := flag.String("filter", "", "comma separated smoothing filters applied before comparing, e.g. median:5,avg:3,ema:0.5")
+	streaming := flag.Bool("stream", false, "process measurements one at a time as they arrive (use - to read stdin)")
+	reportEvery := flag.Int("report-every", 1000, "in stream mode, print the running count to stderr every N measurements (never if 0)")
+	profile := flag.Bool("report", false, "print depth statistics alongside the number of increases")
+	bucketWidth := flag.Int("bucket", 10, "width of the depth delta histogram buckets in the report")
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	if *streaming {
+		if err := checkStreamFlags(); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		result, err := runStream(ctx, flag.Arg(0), *windowSize, *reportEvery)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		fmt.Println(result)
+		return
+	}
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 1, Part: fmt.Sprintf("window=%d filter=%s", *windowSize, *filterSpec), Source: source}
+	solve := func() (interface{}, error) {
+		return run(ctx, flag.Arg(0), *windowSize, *filterSpec)
+	}
+	if *profile {
+		if *filterSpec != "" {
+			fmt.Fprintln(os.Stderr, "-report cannot be combined with -filter")
+			os.Exit(2)
+		}
+
+		puzzle.Part = fmt.Sprintf("report window=%d bucket=%d", *windowSize, *bucketWidth)
+		solve = func() (interface{}, error) {
+			return runProfile(ctx, flag.Arg(0), *windowSize, *bucketWidth)
+		}
+	}
+
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache, solve)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -17,20 +82,41 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string, windowSize int, filterSpec string) (int, error) {
+	if windowSize < 1 {
+		return 0, fmt.Errorf("window size must be positive: %d", windowSize)
+	}
+
+	filters, err := parseFilters(filterSpec)
+	if err != nil {
+		return 0, err
+	}
+
 	measurements, err := loadMeasurements(filepath)
 	if err != nil {
 		return 0, err
 	}
 
-	return increases(measurements), nil
+	if err := timeout.Check(ctx); err != nil {
+		return 0, err
+	}
+
+	if len(filters) > 0 {
+		return seriesIncreases(applyFilters(toSeries(measurements), filters), windowSize), nil
+	}
+
+	return increases(measurements, windowSize), nil
 }
 
-func increases(measurements []int) int {
+// increases counts how many times the sum of a sliding window grows.
+// Adjacent windows share all but one measurement on each end, so comparing
+// the sums boils down to comparing the measurement entering the window with
+// the one leaving it.
+func increases(measurements []int, windowSize int) int {
 	result := 0
 
-	for i := 2; i < len(measurements)-1; i++ {
-		if sumOfThree(measurements, i) > sumOfThree(measurements, i-1) {
+	for i := windowSize; i < len(measurements); i++ {
+		if measurements[i] > measurements[i-windowSize] {
 			result++
 		}
 	}
@@ -38,8 +124,109 @@ func increases(measurements []int) int {
 	return result
 }
 
-func sumOfThree(values []int, middle int) int {
-	return values[middle-1] + values[middle] + values[middle+1]
+// checkStreamFlags rejects the flags stream mode does not support, as it
+// never holds the whole series needed to filter or profile it.
+func checkStreamFlags() error {
+	var err error
+	flag.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "filter", "report", "bucket":
+			if err == nil {
+				err = fmt.Errorf("-stream cannot be combined with -%s", f.Name)
+			}
+		}
+	})
+	return err
+}
+
+func runStream(ctx context.Context, filepath string, windowSize, reportEvery int) (int, error) {
+	if windowSize < 1 {
+		return 0, fmt.Errorf("window size must be positive: %d", windowSize)
+	}
+
+	var r io.Reader = os.Stdin
+	if filepath != "-" {
+		file, err := os.Open(filepath)
+		if err != nil {
+			return 0, fmt.Errorf("failed to open data file: %v", err)
+		}
+		defer file.Close()
+		r = file
+	}
+
+	return streamIncreases(ctx, r, windowSize, reportEvery, os.Stderr)
+}
+
+// streamIncreases counts window increases while reading measurements from r,
+// keeping only the last window in memory.
+func streamIncreases(ctx context.Context, r io.Reader, windowSize, reportEvery int, progress io.Writer) (int, error) {
+	counter := newIncreaseCounter(windowSize)
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		if err := timeout.Check(ctx); err != nil {
+			return 0, err
+		}
+
+		line := scanner.Text()
+		i, err := strconv.Atoi(line)
+		if err != nil {
+			return 0, fmt.Errorf("failed to parse line %s: %v", line, err)
+		}
+
+		counter.add(i)
+		if reportEvery > 0 && counter.seen%reportEvery == 0 {
+			fmt.Fprintf(progress, "measurements: %d increases: %d\n", counter.seen, counter.increases)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read measurements: %v", err)
+	}
+
+	return counter.increases, nil
+}
+
+type increaseCounter struct {
+	window    []int
+	seen      int
+	increases int
+}
+
+func newIncreaseCounter(windowSize int) *increaseCounter {
+	return &increaseCounter{
+		window: make([]int, windowSize),
+	}
+}
+
+// add compares the new measurement with the one leaving the window, which is
+// stored in the ring buffer slot the new measurement is about to take.
+func (c *increaseCounter) add(measurement int) {
+	slot := c.seen % len(c.window)
+	if c.seen >= len(c.window) && measurement > c.window[slot] {
+		c.increases++
+	}
+
+	c.window[slot] = measurement
+	c.seen++
+}
+
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	measurements, err := loadMeasurements(filepath)
+	if err != nil {
+		return err
+	}
+
+	min, max := inspect.Range(measurements)
+	inspect.Print("lines", lineCount)
+	inspect.Print("measurements", len(measurements))
+	inspect.Print("range", fmt.Sprintf("%d..%d", min, max))
+	return nil
 }
 
 func loadMeasurements(filepath string) ([]int, error) {
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/skhalash/adventofcode/internal/timeout"
+)
+
+type depthProfile struct {
+	increases            int
+	longestIncreasingRun int
+	largestDrop          int
+	min, max             int
+	mean                 float64
+	bucketWidth          int
+	deltaHistogram       map[int]int
+}
+
+func runProfile(ctx context.Context, filepath string, windowSize, bucketWidth int) (*depthProfile, error) {
+	if windowSize < 1 {
+		return nil, fmt.Errorf("window size must be positive: %d", windowSize)
+	}
+	if bucketWidth < 1 {
+		return nil, fmt.Errorf("histogram bucket width must be positive: %d", bucketWidth)
+	}
+
+	measurements, err := loadMeasurements(filepath)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := timeout.Check(ctx); err != nil {
+		return nil, err
+	}
+
+	if len(measurements) == 0 {
+		return nil, fmt.Errorf("no measurements")
+	}
+
+	return newDepthProfile(measurements, windowSize, bucketWidth), nil
+}
+
+func newDepthProfile(measurements []int, windowSize, bucketWidth int) *depthProfile {
+	p := &depthProfile{
+		increases:            increases(measurements, windowSize),
+		longestIncreasingRun: 1,
+		min:                  measurements[0],
+		max:                  measurements[0],
+		bucketWidth:          bucketWidth,
+		deltaHistogram:       make(map[int]int),
+	}
+
+	sum, run := 0, 1
+	for i, m := range measurements {
+		sum += m
+		if m < p.min {
+			p.min = m
+		}
+		if m > p.max {
+			p.max = m
+		}
+
+		if i == 0 {
+			continue
+		}
+
+		delta := m - measurements[i-1]
+		p.deltaHistogram[bucket(delta, bucketWidth)]++
+
+		if delta > 0 {
+			run++
+			if run > p.longestIncreasingRun {
+				p.longestIncreasingRun = run
+			}
+		} else {
+			run = 1
+		}
+
+		if -delta > p.largestDrop {
+			p.largestDrop = -delta
+		}
+	}
+
+	p.mean = float64(sum) / float64(len(measurements))
+	return p
+}
+
+// bucket returns the lower bound of the histogram bucket holding delta,
+// rounding towards negative infinity so that buckets never straddle zero.
+func bucket(delta, width int) int {
+	if delta < 0 {
+		return -((-delta + width - 1) / width) * width
+	}
+	return delta / width * width
+}
+
+func (p *depthProfile) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "increases: %d\n", p.increases)
+	fmt.Fprintf(&sb, "longest increasing run: %d\n", p.longestIncreasingRun)
+	fmt.Fprintf(&sb, "largest drop: %d\n", p.largestDrop)
+	fmt.Fprintf(&sb, "min depth: %d\n", p.min)
+	fmt.Fprintf(&sb, "max depth: %d\n", p.max)
+	fmt.Fprintf(&sb, "mean depth: %.2f\n", p.mean)
+	sb.WriteString("delta histogram:")
+
+	var buckets []int
+	for b := range p.deltaHistogram {
+		buckets = append(buckets, b)
+	}
+	sort.Ints(buckets)
+
+	for _, b := range buckets {
+		fmt.Fprintf(&sb, "\n  %d..%d: %d", b, b+p.bucketWidth-1, p.deltaHistogram[b])
+	}
+
+	return sb.String()
+}
@@ -2,9 +2,18 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"errors"
+	"flag"
 	"fmt"
 	"os"
 	"sort"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
+	"github.com/skhalash/adventofcode/internal/trace"
 )
 
 type stack []rune
@@ -28,8 +37,50 @@ func (s *stack) empty() bool {
 	return len(*s) == 0
 }
 
+type illegalBracketError struct {
+	pos     int
+	opening rune
+	found   rune
+}
+
+func (e *illegalBracketError) Error() string {
+	if e.opening == 0 {
+		return fmt.Sprintf("no opening bracket for %c", e.found)
+	}
+	return fmt.Sprintf("non matching brackets %c,%c", e.opening, e.found)
+}
+
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	newTracer := trace.Flags()
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	tracer, err := newTracer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 10, Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache && !tracer.Enabled(), func() (interface{}, error) {
+		return run(ctx, flag.Arg(0), tracer)
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -38,17 +89,36 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string, tracer *trace.Tracer) (int, error) {
 	brackets, err := loadBrackets(filepath)
 	if err != nil {
 		return 0, err
 	}
 
 	var scores []int
-	for _, br := range brackets {
-		if score, err := autocompletionScore(br); err == nil {
-			scores = append(scores, score)
+	for i, br := range brackets {
+		if err := timeout.Check(ctx); err != nil {
+			return 0, err
+		}
+
+		score, err := autocompletionScore(br)
+		if err != nil {
+			var illegal *illegalBracketError
+			if errors.As(err, &illegal) {
+				expected := ""
+				if illegal.opening != 0 {
+					expected = string(closingFor(illegal.opening))
+				}
+				tracer.Event("illegal",
+					trace.F("line", i+1),
+					trace.F("column", illegal.pos+1),
+					trace.F("found", string(illegal.found)),
+					trace.F("expected", expected))
+			}
+			continue
 		}
+
+		scores = append(scores, score)
 	}
 
 	sort.Ints(scores)
@@ -59,7 +129,7 @@ func run(filepath string) (int, error) {
 func autocompletionScore(brackets []rune) (int, error) {
 	unmatched, err := balance(brackets)
 	if err != nil {
-		return 0, fmt.Errorf("unbalanced brackets: %v", err)
+		return 0, fmt.Errorf("unbalanced brackets: %w", err)
 	}
 
 	totalScore := 0
@@ -88,7 +158,7 @@ func bracketScore(b rune) int {
 
 func balance(brackets []rune) (stack, error) {
 	var unmatched stack
-	for _, bracket := range brackets {
+	for i, bracket := range brackets {
 		if opening(bracket) {
 			unmatched.push(bracket)
 			continue
@@ -96,12 +166,12 @@ func balance(brackets []rune) (stack, error) {
 
 		if closing(bracket) {
 			if unmatched.empty() {
-				return nil, fmt.Errorf("no opening bracket for %c", bracket)
+				return nil, &illegalBracketError{pos: i, found: bracket}
 			}
 
 			top, _ := unmatched.pop()
 			if !matching(top, bracket) {
-				return nil, fmt.Errorf("non matching brackets %c,%c", top, bracket)
+				return nil, &illegalBracketError{pos: i, opening: top, found: bracket}
 			}
 		}
 	}
@@ -132,6 +202,44 @@ func matching(opening, closing rune) bool {
 		opening == '<' && closing == '>'
 }
 
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	brackets, err := loadBrackets(filepath)
+	if err != nil {
+		return err
+	}
+
+	longest := 0
+	for _, br := range brackets {
+		if len(br) > longest {
+			longest = len(br)
+		}
+	}
+
+	inspect.Print("lines", lineCount)
+	inspect.Print("chunks", len(brackets))
+	inspect.Print("longest chunk", longest)
+	return nil
+}
+
+func closingFor(opening rune) rune {
+	switch opening {
+	case '(':
+		return ')'
+	case '[':
+		return ']'
+	case '{':
+		return '}'
+	case '<':
+		return '>'
+	}
+	return 0
+}
+
 func loadBrackets(filepath string) ([][]rune, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
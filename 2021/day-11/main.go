@@ -2,19 +2,47 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
-)
 
-const gridSize = 10
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
+)
 
 type coordinate struct {
 	x, y int
 }
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	steps := flag.Int("steps", 0, "number of steps to count flashes for (run until all octopuses flash if 0)")
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 11, Part: fmt.Sprintf("steps=%d", *steps), Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache, func() (interface{}, error) {
+		return run(ctx, flag.Arg(0), *steps)
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -23,17 +51,44 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string, steps int) (int, error) {
 	octopuses, err := loadOctopusGrid(filepath)
 	if err != nil {
 		return 0, err
 	}
 
+	if steps > 0 {
+		return flashCount(ctx, octopuses, steps)
+	}
+
+	return firstSynchronizedStep(ctx, octopuses)
+}
+
+func flashCount(ctx context.Context, octopuses [][]int, steps int) (int, error) {
+	total := 0
+	for step := 0; step < steps; step++ {
+		if err := timeout.Check(ctx); err != nil {
+			return 0, err
+		}
+
+		total += nextStep(octopuses)
+	}
+
+	return total, nil
+}
+
+func firstSynchronizedStep(ctx context.Context, octopuses [][]int) (int, error) {
+	octopusCount := len(octopuses) * len(octopuses[0])
+
 	step := 0
 	for {
-		allFlashed := nextStep(octopuses)
+		if err := timeout.Check(ctx); err != nil {
+			return 0, err
+		}
+
+		flashed := nextStep(octopuses)
 		step++
-		if allFlashed {
+		if flashed == octopusCount {
 			break
 		}
 	}
@@ -41,24 +96,24 @@ func run(filepath string) (int, error) {
 	return step, nil
 }
 
-func nextStep(octopuses [][]int) bool {
-	for x := 0; x < gridSize; x++ {
-		for y := 0; y < gridSize; y++ {
+func nextStep(octopuses [][]int) int {
+	for x := range octopuses {
+		for y := range octopuses[x] {
 			octopuses[x][y]++
 		}
 	}
 
 	flashed := make(map[coordinate]bool)
 
-	for x := 0; x < gridSize; x++ {
-		for y := 0; y < gridSize; y++ {
+	for x := range octopuses {
+		for y := range octopuses[x] {
 			if energized(x, y, octopuses) {
 				flash(coordinate{x, y}, octopuses, flashed)
 			}
 		}
 	}
 
-	return len(flashed) == gridSize*gridSize
+	return len(flashed)
 }
 
 func flash(current coordinate, octopuses [][]int, flashed map[coordinate]bool) {
@@ -72,7 +127,7 @@ func flash(current coordinate, octopuses [][]int, flashed map[coordinate]bool) {
 
 			adjX := current.x + dx
 			adjY := current.y + dy
-			hasAdjacent := adjX >= 0 && adjX < gridSize && adjY >= 0 && adjY < gridSize
+			hasAdjacent := adjX >= 0 && adjX < len(octopuses) && adjY >= 0 && adjY < len(octopuses[adjX])
 			if !hasAdjacent {
 				continue
 			}
@@ -96,6 +151,22 @@ func energized(i, j int, octopuses [][]int) bool {
 	return octopuses[i][j] > 9
 }
 
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	octopuses, err := loadOctopusGrid(filepath)
+	if err != nil {
+		return err
+	}
+
+	inspect.Print("lines", lineCount)
+	inspect.Print("grid", fmt.Sprintf("%dx%d", len(octopuses), len(octopuses[0])))
+	return nil
+}
+
 func loadOctopusGrid(filepath string) ([][]int, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -108,8 +179,8 @@ func loadOctopusGrid(filepath string) ([][]int, error) {
 	for scanner.Scan() {
 		var row []int
 		line := scanner.Text()
-		if len(line) != gridSize {
-			return nil, fmt.Errorf("must have %d columns", gridSize)
+		if len(result) > 0 && len(line) != len(result[0]) {
+			return nil, fmt.Errorf("must have %d columns", len(result[0]))
 		}
 
 		for _, char := range line {
@@ -123,8 +194,8 @@ func loadOctopusGrid(filepath string) ([][]int, error) {
 		result = append(result, row)
 	}
 
-	if len(result) != gridSize {
-		return nil, fmt.Errorf("must have %d rows", gridSize)
+	if len(result) == 0 || len(result[0]) == 0 {
+		return nil, fmt.Errorf("must have at least one octopus")
 	}
 
 	return result, nil
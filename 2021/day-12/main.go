@@ -2,9 +2,16 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
 )
 
 type node struct {
@@ -59,8 +66,30 @@ func (g *graph) addEdge(from, to node) {
 	g.adjacent[from] = append(g.adjacent[from], to)
 }
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 12, Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache, func() (interface{}, error) {
+		return run(ctx, flag.Arg(0))
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -69,24 +98,28 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string) (int, error) {
 	g, err := loadGraph(filepath)
 	if err != nil {
 		return 0, err
 	}
 
-	return dfs(g.start(), g, make(map[node]bool), true), nil
+	return dfs(ctx, g.start(), g, make(map[node]bool), true)
 }
 
-func dfs(n node, g *graph, seen map[node]bool, canRevisit bool) int {
+func dfs(ctx context.Context, n node, g *graph, seen map[node]bool, canRevisit bool) (int, error) {
+	if err := timeout.Check(ctx); err != nil {
+		return 0, err
+	}
+
 	if n.end() {
-		return 1
+		return 1, nil
 	}
 
 	if n.small() {
 		if seen, exists := seen[n]; exists && seen {
 			if !canRevisit || n.start() {
-				return 0
+				return 0, nil
 			}
 			if !n.start() {
 				canRevisit = false
@@ -97,9 +130,42 @@ func dfs(n node, g *graph, seen map[node]bool, canRevisit bool) int {
 
 	total := 0
 	for _, next := range g.neighbours(n) {
-		total += dfs(next, g, copy(seen), canRevisit)
+		paths, err := dfs(ctx, next, g, copy(seen), canRevisit)
+		if err != nil {
+			return 0, err
+		}
+		total += paths
 	}
-	return total
+	return total, nil
+}
+
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	g, err := loadGraph(filepath)
+	if err != nil {
+		return err
+	}
+
+	edges, small, large := 0, 0, 0
+	for _, n := range g.nodes() {
+		edges += len(g.neighbours(n))
+		if n.small() {
+			small++
+		} else {
+			large++
+		}
+	}
+
+	inspect.Print("lines", lineCount)
+	inspect.Print("nodes", len(g.nodes()))
+	inspect.Print("edges", edges/2)
+	inspect.Print("small caves", small)
+	inspect.Print("large caves", large)
+	return nil
 }
 
 func copy(source map[node]bool) map[node]bool {
@@ -0,0 +1,40 @@
+package main
+
+import "fmt"
+
+// constraints are physical invariants checked after every command in strict
+// mode. Zero limits are not enforced; depth must never be negative.
+type constraints struct {
+	maxAim   int
+	maxDepth int
+}
+
+func (c constraints) String() string {
+	return fmt.Sprintf("max-aim=%d max-depth=%d", c.maxAim, c.maxDepth)
+}
+
+func (c constraints) check(s submarine) error {
+	if s.depth < 0 {
+		return fmt.Errorf("depth %d is negative", s.depth)
+	}
+	if c.maxDepth > 0 && s.depth > c.maxDepth {
+		return fmt.Errorf("depth %d exceeds %d", s.depth, c.maxDepth)
+	}
+	if c.maxAim > 0 && abs(s.aim) > c.maxAim {
+		return fmt.Errorf("aim %d exceeds ±%d", s.aim, c.maxAim)
+	}
+	return nil
+}
+
+// strictCourse is course that stops at the first command breaking the constraints.
+func strictCourse(commands []command, model movementModel, limits constraints) (submarine, error) {
+	var s submarine
+	for _, c := range commands {
+		model(&s, c)
+		if err := limits.check(s); err != nil {
+			return submarine{}, fmt.Errorf("line %d: %s %d: %v", c.line, c.commandType, c.units, err)
+		}
+	}
+
+	return s, nil
+}
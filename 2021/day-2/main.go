@@ -1,11 +1,15 @@
 package main
 
 import (
-	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"os"
-	"strconv"
-	"strings"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
 )
 
 type commandType string
@@ -17,10 +21,64 @@ var down commandType = "down"
 type command struct {
 	commandType commandType
 	units       int
+	line        int
 }
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	modelName := flag.String("model", "aim", "movement model, simple or aim")
+	trajectoryPath := flag.String("trajectory", "", "export the submarine trajectory to a .csv or .svg file")
+	target := flag.String("plan", "", "print a shortest aim model course reaching the given position,depth instead of solving")
+	strict := flag.Bool("strict", false, "fail on the first command leaving the submarine in an impossible state")
+	maxAim := flag.Int("max-aim", 0, "in strict mode, the largest allowed absolute aim (unbounded if 0)")
+	maxDepth := flag.Int("max-depth", 0, "in strict mode, the largest allowed depth (unbounded if 0)")
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	if *target != "" {
+		plan, err := runPlanner(*target)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+
+		fmt.Print(formatCommands(plan))
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	if *trajectoryPath != "" {
+		if err := exportTrajectory(ctx, flag.Arg(0), *modelName, *trajectoryPath); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	var limits *constraints
+	part := fmt.Sprintf("model=%s", *modelName)
+	if *strict {
+		limits = &constraints{maxAim: *maxAim, maxDepth: *maxDepth}
+		part = fmt.Sprintf("%s strict %s", part, limits)
+	}
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 2, Part: part, Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache, func() (interface{}, error) {
+		return run(ctx, flag.Arg(0), *modelName, limits)
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -29,63 +87,87 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
-	commands, err := loadCommands(filepath)
+func run(ctx context.Context, filepath string, modelName string, limits *constraints) (*submarine, error) {
+	model, err := movementModelByName(modelName)
+	if err != nil {
+		return nil, err
+	}
+
+	commands, err := loadCommands(ctx, filepath)
 	if err != nil {
-		return 0, err
+		return nil, err
+	}
+
+	if limits != nil {
+		s, err := strictCourse(commands, model, *limits)
+		if err != nil {
+			return nil, fmt.Errorf("constraint violated: %v", err)
+		}
+		return &s, nil
 	}
 
-	return course(commands), nil
+	s := course(commands, model)
+	return &s, nil
 }
 
-func course(commands []command) int {
-	pos, depth, aim := 0, 0, 0
+func runPlanner(target string) ([]command, error) {
+	pos, depth, err := parseTarget(target)
+	if err != nil {
+		return nil, err
+	}
+
+	return planRoute(pos, depth)
+}
 
+func course(commands []command, model movementModel) submarine {
+	var s submarine
 	for _, c := range commands {
-		switch c.commandType {
-		case down:
-			aim += c.units
-		case up:
-			aim -= c.units
-		case forward:
-			pos += c.units
-			depth += (c.units * aim)
-		}
+		model(&s, c)
 	}
 
-	return pos * depth
+	return s
 }
 
-func loadCommands(filepath string) ([]command, error) {
-	file, err := os.Open(filepath)
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open data file: %v", err)
+		return err
 	}
 
-	var result []command
+	commands, err := loadCommands(context.Background(), filepath)
+	if err != nil {
+		return err
+	}
 
-	scanner := bufio.NewScanner(file)
-	for scanner.Scan() {
-		line := scanner.Text()
-		parts := strings.Split(line, " ")
-		if len(parts) != 2 {
-			return nil, fmt.Errorf("must contain two parts %s: %v", line, err)
-		}
+	countByType := make(map[commandType]int)
+	for _, c := range commands {
+		countByType[c.commandType]++
+	}
 
-		commandType := commandType(parts[0])
-		switch commandType {
-		case forward, up, down:
-		default:
-			return nil, fmt.Errorf("invalid command type: %s", commandType)
-		}
+	inspect.Print("lines", lineCount)
+	inspect.Print("commands", len(commands))
+	for _, ct := range []commandType{forward, up, down} {
+		inspect.Print(string(ct), countByType[ct])
+	}
+	return nil
+}
 
-		units, err := strconv.Atoi(parts[1])
-		if err != nil {
-			return nil, fmt.Errorf("failed to parse units %s: %v", parts[1], err)
-		}
+func loadCommands(ctx context.Context, filepath string) ([]command, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open data file: %v", err)
+	}
+	defer file.Close()
+
+	statements, err := parseScript(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse commands: %v", err)
+	}
 
-		result = append(result, command{commandType: commandType, units: units})
+	commands, err := expand(ctx, statements)
+	if err != nil {
+		return nil, fmt.Errorf("failed to expand commands: %v", err)
 	}
 
-	return result, nil
+	return commands, nil
 }
@@ -0,0 +1,52 @@
+package main
+
+import "fmt"
+
+type submarine struct {
+	pos, depth, aim int
+}
+
+func (s submarine) product() int {
+	return s.pos * s.depth
+}
+
+func (s submarine) String() string {
+	return fmt.Sprintf("position: %d\ndepth: %d\nproduct: %d", s.pos, s.depth, s.product())
+}
+
+// movementModel defines how a single command changes the submarine state.
+type movementModel func(s *submarine, c command)
+
+func movementModelByName(name string) (movementModel, error) {
+	switch name {
+	case "simple":
+		return simpleMovement, nil
+	case "aim":
+		return aimMovement, nil
+	}
+
+	return nil, fmt.Errorf("unknown movement model %s", name)
+}
+
+func simpleMovement(s *submarine, c command) {
+	switch c.commandType {
+	case down:
+		s.depth += c.units
+	case up:
+		s.depth -= c.units
+	case forward:
+		s.pos += c.units
+	}
+}
+
+func aimMovement(s *submarine, c command) {
+	switch c.commandType {
+	case down:
+		s.aim += c.units
+	case up:
+		s.aim -= c.units
+	case forward:
+		s.pos += c.units
+		s.depth += c.units * s.aim
+	}
+}
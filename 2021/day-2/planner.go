@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// planRoute returns a shortest command list that takes the submarine from the
+// surface to the target under the aim model. Forward commands are the only
+// way to gain depth, and the depth gained equals units * aim, so:
+//
+//   - a target on the surface needs a single forward command,
+//   - if the position divides the depth, setting the aim once up front and
+//     moving forward once is enough,
+//   - otherwise, for any divisor d of the depth smaller than the position,
+//     moving forward position-d, setting the aim to depth/d and moving
+//     forward d hits the target. d = 1 always exists.
+func planRoute(pos, depth int) ([]command, error) {
+	if pos < 0 {
+		return nil, fmt.Errorf("unreachable target %d,%d: position must not be negative", pos, depth)
+	}
+
+	var plan []command
+	switch {
+	case pos == 0 && depth == 0:
+	case pos == 0:
+		return nil, fmt.Errorf("unreachable target %d,%d: depth can't change without moving forward", pos, depth)
+	case depth == 0:
+		plan = []command{{commandType: forward, units: pos}}
+	case depth%pos == 0:
+		plan = []command{aimCommand(depth / pos), {commandType: forward, units: pos}}
+	default:
+		d := largestDivisorBelow(abs(depth), pos)
+		plan = []command{
+			{commandType: forward, units: pos - d},
+			aimCommand(depth / d),
+			{commandType: forward, units: d},
+		}
+	}
+
+	if err := validatePlan(plan, pos, depth); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+func aimCommand(aim int) command {
+	if aim < 0 {
+		return command{commandType: up, units: -aim}
+	}
+	return command{commandType: down, units: aim}
+}
+
+func largestDivisorBelow(n, limit int) int {
+	for d := limit - 1; d > 1; d-- {
+		if n%d == 0 {
+			return d
+		}
+	}
+	return 1
+}
+
+func validatePlan(plan []command, pos, depth int) error {
+	s := course(plan, aimMovement)
+	if s.pos != pos || s.depth != depth {
+		return fmt.Errorf("plan reaches %d,%d instead of %d,%d", s.pos, s.depth, pos, depth)
+	}
+	return nil
+}
+
+func parseTarget(s string) (int, int, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 2 {
+		return 0, 0, fmt.Errorf("target must have the form position,depth %s", s)
+	}
+
+	pos, err := strconv.Atoi(parts[0])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse position %s: %v", parts[0], err)
+	}
+
+	depth, err := strconv.Atoi(parts[1])
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to parse depth %s: %v", parts[1], err)
+	}
+
+	return pos, depth, nil
+}
+
+func formatCommands(commands []command) string {
+	var sb strings.Builder
+	for _, c := range commands {
+		fmt.Fprintf(&sb, "%s %d\n", c.commandType, c.units)
+	}
+	return sb.String()
+}
+
+func abs(x int) int {
+	if x > 0 {
+		return x
+	}
+	return -x
+}
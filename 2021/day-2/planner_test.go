@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestPlanRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		pos       int
+		depth     int
+		length    int
+		wantError bool
+	}{
+		{name: "start", pos: 0, depth: 0, length: 0},
+		{name: "surface", pos: 10, depth: 0, length: 1},
+		{name: "position divides depth", pos: 5, depth: 15, length: 2},
+		{name: "divisor below position", pos: 4, depth: 6, length: 3},
+		{name: "prime depth", pos: 5, depth: 7, length: 3},
+		{name: "negative depth", pos: 4, depth: -8, length: 2},
+		{name: "negative depth not divisible", pos: 4, depth: -9, length: 3},
+		{name: "depth without moving forward", pos: 0, depth: 5, wantError: true},
+		{name: "negative position", pos: -1, depth: 3, wantError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			plan, err := planRoute(tt.pos, tt.depth)
+			if tt.wantError {
+				if err == nil {
+					t.Fatalf("got plan %v, want an error", plan)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(plan) != tt.length {
+				t.Errorf("got %d commands %v, want %d", len(plan), plan, tt.length)
+			}
+			if err := validatePlan(plan, tt.pos, tt.depth); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -0,0 +1,475 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/skhalash/adventofcode/internal/timeout"
+)
+
+// Course scripts extend the plain command list with comments, variables,
+// repeat blocks and macros:
+//
+//	# descend in three steps
+//	let depth = 2
+//	macro dive {
+//	    down depth
+//	    forward 1
+//	}
+//	repeat 3 { dive }
+//
+// A script is parsed into statements which are then expanded into the
+// plain commands understood by the movement models.
+
+const commentPrefix = "#"
+const maxMacroDepth = 64
+
+type tokenKind int
+
+const (
+	wordToken tokenKind = iota
+	numberToken
+	openBraceToken
+	closeBraceToken
+	assignToken
+	newlineToken
+	eofToken
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	line int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case newlineToken:
+		return "end of line"
+	case eofToken:
+		return "end of file"
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+type syntaxError struct {
+	line int
+	msg  string
+}
+
+func (e *syntaxError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.line, e.msg)
+}
+
+func errorAt(line int, format string, args ...interface{}) error {
+	return &syntaxError{line: line, msg: fmt.Sprintf(format, args...)}
+}
+
+func tokenize(r io.Reader) ([]token, error) {
+	var result []token
+
+	line := 0
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line++
+		text := scanner.Text()
+		if i := strings.Index(text, commentPrefix); i >= 0 {
+			text = text[:i]
+		}
+
+		text = strings.NewReplacer("{", " { ", "}", " } ", "=", " = ").Replace(text)
+		for _, field := range strings.Fields(text) {
+			t, err := newToken(field, line)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, t)
+		}
+
+		result = append(result, token{kind: newlineToken, line: line})
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read script: %v", err)
+	}
+
+	return append(result, token{kind: eofToken, line: line}), nil
+}
+
+func newToken(field string, line int) (token, error) {
+	switch field {
+	case "{":
+		return token{kind: openBraceToken, text: field, line: line}, nil
+	case "}":
+		return token{kind: closeBraceToken, text: field, line: line}, nil
+	case "=":
+		return token{kind: assignToken, text: field, line: line}, nil
+	}
+
+	if _, err := strconv.Atoi(field); err == nil {
+		return token{kind: numberToken, text: field, line: line}, nil
+	}
+
+	if !validIdentifier(field) {
+		return token{}, errorAt(line, "unexpected %q", field)
+	}
+	return token{kind: wordToken, text: field, line: line}, nil
+}
+
+func validIdentifier(s string) bool {
+	for i, r := range s {
+		if r == '_' || unicode.IsLetter(r) || i > 0 && unicode.IsDigit(r) {
+			continue
+		}
+		return false
+	}
+	return s != ""
+}
+
+// value is either an integer literal or a reference to a variable.
+type value struct {
+	line    int
+	literal int
+	name    string
+}
+
+type statement interface {
+	exec(e *executor) error
+}
+
+type motionStatement struct {
+	line        int
+	commandType commandType
+	units       value
+}
+
+type letStatement struct {
+	line  int
+	name  string
+	value value
+}
+
+type repeatStatement struct {
+	line  int
+	count value
+	body  []statement
+}
+
+type macroStatement struct {
+	line int
+	name string
+	body []statement
+}
+
+type callStatement struct {
+	line int
+	name string
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func parseScript(r io.Reader) ([]statement, error) {
+	tokens, err := tokenize(r)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	statements, err := p.statements()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.peek(); t.kind != eofToken {
+		return nil, errorAt(t.line, "unexpected %s", t)
+	}
+	return statements, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != eofToken {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	t := p.next()
+	if t.kind != kind {
+		return token{}, errorAt(t.line, "expected %s, found %s", what, t)
+	}
+	return t, nil
+}
+
+// statements parses statements up to a closing brace or the end of file.
+func (p *parser) statements() ([]statement, error) {
+	var result []statement
+	for {
+		switch p.peek().kind {
+		case newlineToken:
+			p.next()
+			continue
+		case closeBraceToken, eofToken:
+			return result, nil
+		}
+
+		s, err := p.statement()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, s)
+	}
+}
+
+func (p *parser) statement() (statement, error) {
+	t, err := p.expect(wordToken, "statement")
+	if err != nil {
+		return nil, err
+	}
+
+	var s statement
+	switch t.text {
+	case string(forward), string(up), string(down):
+		units, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		s = &motionStatement{line: t.line, commandType: commandType(t.text), units: units}
+
+	case "let":
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(assignToken, "="); err != nil {
+			return nil, err
+		}
+		v, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		s = &letStatement{line: t.line, name: name, value: v}
+
+	case "repeat":
+		count, err := p.value()
+		if err != nil {
+			return nil, err
+		}
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		s = &repeatStatement{line: t.line, count: count, body: body}
+
+	case "macro":
+		name, err := p.name()
+		if err != nil {
+			return nil, err
+		}
+		body, err := p.block()
+		if err != nil {
+			return nil, err
+		}
+		s = &macroStatement{line: t.line, name: name, body: body}
+
+	default:
+		// Macro calls take no arguments, so a word followed by more tokens is
+		// most likely a misspelled command.
+		switch p.peek().kind {
+		case newlineToken, closeBraceToken, eofToken:
+		default:
+			return nil, errorAt(t.line, "unknown command or macro %s", t.text)
+		}
+		s = &callStatement{line: t.line, name: t.text}
+	}
+
+	if err := p.endOfStatement(); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func (p *parser) endOfStatement() error {
+	switch t := p.peek(); t.kind {
+	case newlineToken:
+		p.next()
+		return nil
+	case closeBraceToken, eofToken:
+		return nil
+	default:
+		return errorAt(t.line, "unexpected %s", t)
+	}
+}
+
+func (p *parser) block() ([]statement, error) {
+	open, err := p.expect(openBraceToken, "{")
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := p.statements()
+	if err != nil {
+		return nil, err
+	}
+
+	if t := p.next(); t.kind != closeBraceToken {
+		return nil, errorAt(open.line, "unclosed {")
+	}
+	return body, nil
+}
+
+func (p *parser) name() (string, error) {
+	t, err := p.expect(wordToken, "name")
+	if err != nil {
+		return "", err
+	}
+	if reserved(t.text) {
+		return "", errorAt(t.line, "%q is a reserved word", t.text)
+	}
+	return t.text, nil
+}
+
+func (p *parser) value() (value, error) {
+	t := p.next()
+	switch t.kind {
+	case numberToken:
+		n, _ := strconv.Atoi(t.text)
+		return value{line: t.line, literal: n}, nil
+	case wordToken:
+		if reserved(t.text) {
+			return value{}, errorAt(t.line, "%q is a reserved word", t.text)
+		}
+		return value{line: t.line, name: t.text}, nil
+	}
+	return value{}, errorAt(t.line, "expected number or variable, found %s", t)
+}
+
+func reserved(word string) bool {
+	switch word {
+	case string(forward), string(up), string(down), "let", "repeat", "macro":
+		return true
+	}
+	return false
+}
+
+// executor expands statements into plain commands.
+type executor struct {
+	ctx       context.Context
+	variables map[string]int
+	macros    map[string][]statement
+	calls     []string
+	commands  []command
+}
+
+func expand(ctx context.Context, statements []statement) ([]command, error) {
+	e := &executor{
+		ctx:       ctx,
+		variables: make(map[string]int),
+		macros:    make(map[string][]statement),
+	}
+
+	if err := e.execAll(statements); err != nil {
+		return nil, err
+	}
+	return e.commands, nil
+}
+
+func (e *executor) execAll(statements []statement) error {
+	for _, s := range statements {
+		if err := s.exec(e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (e *executor) eval(v value) (int, error) {
+	if v.name == "" {
+		return v.literal, nil
+	}
+
+	n, found := e.variables[v.name]
+	if !found {
+		return 0, errorAt(v.line, "undefined variable %s", v.name)
+	}
+	return n, nil
+}
+
+func (s *motionStatement) exec(e *executor) error {
+	units, err := e.eval(s.units)
+	if err != nil {
+		return err
+	}
+
+	e.commands = append(e.commands, command{commandType: s.commandType, units: units, line: s.line})
+	return nil
+}
+
+func (s *letStatement) exec(e *executor) error {
+	n, err := e.eval(s.value)
+	if err != nil {
+		return err
+	}
+
+	e.variables[s.name] = n
+	return nil
+}
+
+func (s *repeatStatement) exec(e *executor) error {
+	count, err := e.eval(s.count)
+	if err != nil {
+		return err
+	}
+	if count < 0 {
+		return errorAt(s.line, "repeat count must not be negative: %d", count)
+	}
+
+	for i := 0; i < count; i++ {
+		if err := timeout.Check(e.ctx); err != nil {
+			return err
+		}
+		if err := e.execAll(s.body); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *macroStatement) exec(e *executor) error {
+	e.macros[s.name] = s.body
+	return nil
+}
+
+func (s *callStatement) exec(e *executor) error {
+	body, found := e.macros[s.name]
+	if !found {
+		return errorAt(s.line, "undefined macro %s", s.name)
+	}
+
+	for _, name := range e.calls {
+		if name == s.name {
+			return errorAt(s.line, "recursive call of macro %s", s.name)
+		}
+	}
+	if len(e.calls) == maxMacroDepth {
+		return errorAt(s.line, "macro calls nested deeper than %d", maxMacroDepth)
+	}
+
+	e.calls = append(e.calls, s.name)
+	defer func() { e.calls = e.calls[:len(e.calls)-1] }()
+
+	return e.execAll(body)
+}
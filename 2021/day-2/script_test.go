@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		script  string
+		want    []command
+		wantErr string
+	}{
+		{
+			name:   "macro called in a repeat block",
+			script: "macro dive {\n  down 2\n  forward 1\n}\nrepeat 2 { dive }\n",
+			want: []command{
+				{commandType: down, units: 2, line: 2},
+				{commandType: forward, units: 1, line: 3},
+				{commandType: down, units: 2, line: 2},
+				{commandType: forward, units: 1, line: 3},
+			},
+		},
+		{
+			name:   "macro defined in a repeat block",
+			script: "let n = 3\nrepeat 2 {\n  macro m { forward n }\n  m\n}\n",
+			want: []command{
+				{commandType: forward, units: 3, line: 3},
+				{commandType: forward, units: 3, line: 3},
+			},
+		},
+		{
+			name:    "undefined variable",
+			script:  "forward 1\ndown depth\n",
+			wantErr: "line 2: undefined variable depth",
+		},
+		{
+			name:    "undefined macro",
+			script:  "forward 1\ndive\n",
+			wantErr: "line 2: undefined macro dive",
+		},
+		{
+			name:    "recursive macro",
+			script:  "macro a { b }\nmacro b { a }\na\n",
+			wantErr: "line 2: recursive call of macro a",
+		},
+		{
+			name:    "unclosed brace",
+			script:  "forward 1\nrepeat 2 {\n  forward 1\n",
+			wantErr: "line 2: unclosed {",
+		},
+		{
+			name:    "misspelled command",
+			script:  "forward 1\nforwrd 5\n",
+			wantErr: "line 2: unknown command or macro forwrd",
+		},
+		{
+			name:    "statement after closing brace",
+			script:  "forward 1\nrepeat 2 { forward 1 } down 2\n",
+			wantErr: `line 2: unexpected "down"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := runScript(tt.script)
+			if tt.wantErr != "" {
+				if err == nil || err.Error() != tt.wantErr {
+					t.Fatalf("got error %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got commands %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func runScript(script string) ([]command, error) {
+	statements, err := parseScript(strings.NewReader(script))
+	if err != nil {
+		return nil, err
+	}
+	return expand(context.Background(), statements)
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const svgWidth = 800
+const svgHeight = 400
+
+// trajectoryPoint is the submarine state after executing a command. The
+// first point of a trajectory is the starting state and has no command.
+type trajectoryPoint struct {
+	step    int
+	command command
+	submarine
+}
+
+func trajectory(commands []command, model movementModel) []trajectoryPoint {
+	var s submarine
+	result := []trajectoryPoint{{submarine: s}}
+	for i, c := range commands {
+		model(&s, c)
+		result = append(result, trajectoryPoint{step: i + 1, command: c, submarine: s})
+	}
+
+	return result
+}
+
+func exportTrajectory(ctx context.Context, inputPath, modelName, outputPath string) error {
+	model, err := movementModelByName(modelName)
+	if err != nil {
+		return err
+	}
+
+	commands, err := loadCommands(ctx, inputPath)
+	if err != nil {
+		return err
+	}
+
+	var write func(w io.Writer, points []trajectoryPoint) error
+	switch strings.ToLower(filepath.Ext(outputPath)) {
+	case ".csv":
+		write = writeTrajectoryCSV
+	case ".svg":
+		write = writeTrajectorySVG
+	default:
+		return fmt.Errorf("unsupported trajectory format %s, must be .csv or .svg", outputPath)
+	}
+
+	file, err := os.Create(outputPath)
+	if err != nil {
+		return fmt.Errorf("failed to create trajectory file: %v", err)
+	}
+
+	if err := write(file, trajectory(commands, model)); err != nil {
+		file.Close()
+		return fmt.Errorf("failed to write trajectory: %v", err)
+	}
+
+	return file.Close()
+}
+
+func writeTrajectoryCSV(w io.Writer, points []trajectoryPoint) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"step", "line", "command", "units", "position", "depth", "aim"})
+	for _, p := range points {
+		var line, commandType, units string
+		if p.step > 0 {
+			line = strconv.Itoa(p.command.line)
+			commandType = string(p.command.commandType)
+			units = strconv.Itoa(p.command.units)
+		}
+
+		cw.Write([]string{
+			strconv.Itoa(p.step),
+			line,
+			commandType,
+			units,
+			strconv.Itoa(p.pos),
+			strconv.Itoa(p.depth),
+			strconv.Itoa(p.aim),
+		})
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeTrajectorySVG plots depth against horizontal position. SVG's y axis
+// points down, which matches depth growing downwards.
+func writeTrajectorySVG(w io.Writer, points []trajectoryPoint) error {
+	minX, minY := math.MaxInt, math.MaxInt
+	maxX, maxY := math.MinInt, math.MinInt
+	var coords []string
+	for _, p := range points {
+		minX, maxX = minInt(minX, p.pos), maxInt(maxX, p.pos)
+		minY, maxY = minInt(minY, p.depth), maxInt(maxY, p.depth)
+		coords = append(coords, fmt.Sprintf("%d,%d", p.pos, p.depth))
+	}
+
+	_, err := fmt.Fprintf(w, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="%d %d %d %d" preserveAspectRatio="none">
+  <polyline points="%s" fill="none" stroke="black" stroke-width="1" vector-effect="non-scaling-stroke"/>
+</svg>
+`, svgWidth, svgHeight, minX, minY, maxInt(maxX-minX, 1), maxInt(maxY-minY, 1), strings.Join(coords, " "))
+	return err
+}
+
+func minInt(x, y int) int {
+	if x < y {
+		return x
+	}
+	return y
+}
+
+func maxInt(x, y int) int {
+	if x > y {
+		return x
+	}
+	return y
+}
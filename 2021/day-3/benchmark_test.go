@@ -0,0 +1,62 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+const benchmarkReportSize = 1000000
+const benchmarkBitCount = 12
+
+func BenchmarkScanRating(b *testing.B) {
+	values, criteria := benchmarkReport(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_, err := ratings(context.Background(), func(ctx context.Context, rt ratingType) (bitset, error) {
+			return scanRating(ctx, values, benchmarkBitCount, rt, criteria[rt], nil)
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkTrieRating(b *testing.B) {
+	values, criteria := benchmarkReport(b)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		root := newTrie(values, benchmarkBitCount)
+		_, err := ratings(context.Background(), func(ctx context.Context, rt ratingType) (bitset, error) {
+			return root.rating(ctx, values, benchmarkBitCount, rt, criteria[rt], nil)
+		})
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func benchmarkReport(b *testing.B) ([]bitset, map[ratingType]bitCriteria) {
+	criteria, err := selectBitCriteria(map[ratingType]string{
+		oxygenGeneratorRating: "most-common",
+		co2ScrubberRating:     "least-common",
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	rnd := rand.New(rand.NewSource(1))
+	values := make([]bitset, benchmarkReportSize)
+	for i := range values {
+		v := newBitset(benchmarkBitCount)
+		for pos := 0; pos < benchmarkBitCount; pos++ {
+			if rnd.Intn(2) == 1 {
+				v.set(pos)
+			}
+		}
+		values[i] = v
+	}
+	return values, criteria
+}
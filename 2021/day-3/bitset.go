@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+const wordSize = 64
+
+// bitset is a binary value of arbitrary width. Bit 0 is the least
+// significant one, i.e. the last digit of the diagnostic report line.
+type bitset struct {
+	width int
+	words []uint64
+}
+
+func newBitset(width int) bitset {
+	return bitset{
+		width: width,
+		words: make([]uint64, (width+wordSize-1)/wordSize),
+	}
+}
+
+func parseBitset(s string) (bitset, error) {
+	b := newBitset(len(s))
+	for i, char := range s {
+		pos := len(s) - 1 - i
+		switch char {
+		case '0':
+		case '1':
+			b.set(pos)
+		default:
+			return bitset{}, fmt.Errorf("invalid binary digit %c", char)
+		}
+	}
+
+	return b, nil
+}
+
+func (b bitset) bit(pos int) bool {
+	return b.words[pos/wordSize]&(1<<(pos%wordSize)) != 0
+}
+
+func (b *bitset) set(pos int) {
+	b.words[pos/wordSize] |= 1 << (pos % wordSize)
+}
+
+func (b bitset) String() string {
+	var sb strings.Builder
+	for pos := b.width - 1; pos >= 0; pos-- {
+		if b.bit(pos) {
+			sb.WriteByte('1')
+		} else {
+			sb.WriteByte('0')
+		}
+	}
+	return sb.String()
+}
+
+func (b bitset) bigInt() *big.Int {
+	result := new(big.Int)
+	for i := len(b.words) - 1; i >= 0; i-- {
+		result.Lsh(result, wordSize)
+		result.Or(result, new(big.Int).SetUint64(b.words[i]))
+	}
+	return result
+}
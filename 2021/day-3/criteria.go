@@ -0,0 +1,59 @@
+package main
+
+import "fmt"
+
+// bitCriteria returns the bit that values must have at a position to be kept,
+// given how many of the remaining values have a zero and a one there.
+type bitCriteria func(zeros, ones int) bool
+
+const bitCriteriaNames = "most-common, most-common-tie-zero, least-common, least-common-tie-one, always-one or always-zero"
+
+func bitCriteriaByName(name string) (bitCriteria, bool) {
+	switch name {
+	case "most-common":
+		return commonBitCriteria(true, true), true
+	case "most-common-tie-zero":
+		return commonBitCriteria(true, false), true
+	case "least-common":
+		return commonBitCriteria(false, false), true
+	case "least-common-tie-one":
+		return commonBitCriteria(false, true), true
+	case "always-one":
+		return func(zeros, ones int) bool { return true }, true
+	case "always-zero":
+		return func(zeros, ones int) bool { return false }, true
+	}
+	return nil, false
+}
+
+// commonBitCriteria keeps the most or the least common bit and resolves ties
+// with tieBit. A bit none of the remaining values has is never chosen,
+// otherwise remaining values agreeing on a bit would all be discarded.
+func commonBitCriteria(mostCommon, tieBit bool) bitCriteria {
+	return func(zeros, ones int) bool {
+		if zeros == ones {
+			return tieBit
+		}
+		if zeros == 0 || ones == 0 {
+			return ones > 0
+		}
+		return (ones > zeros) == mostCommon
+	}
+}
+
+func selectBitCriteria(names map[ratingType]string) (map[ratingType]bitCriteria, error) {
+	result := make(map[ratingType]bitCriteria)
+	for _, rt := range allRatingTypes() {
+		name, found := names[rt]
+		if !found {
+			return nil, fmt.Errorf("no bit criteria for %s", rt)
+		}
+
+		criteria, found := bitCriteriaByName(name)
+		if !found {
+			return nil, fmt.Errorf("unknown bit criteria %s for %s, must be %s", name, rt, bitCriteriaNames)
+		}
+		result[rt] = criteria
+	}
+	return result, nil
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+type diagnostics struct {
+	gamma   bitset
+	epsilon bitset
+	oxygen  bitset
+	co2     bitset
+}
+
+func newDiagnostics(ctx context.Context, values []bitset, bitCount int, rate rater) (*diagnostics, error) {
+	oxygen, err := rate(ctx, oxygenGeneratorRating)
+	if err != nil {
+		return nil, err
+	}
+
+	co2, err := rate(ctx, co2ScrubberRating)
+	if err != nil {
+		return nil, err
+	}
+
+	gamma, epsilon := gammaEpsilonRates(values, bitCount)
+	return &diagnostics{
+		gamma:   gamma,
+		epsilon: epsilon,
+		oxygen:  oxygen,
+		co2:     co2,
+	}, nil
+}
+
+// gammaEpsilonRates builds the gamma rate from the most common bit at each
+// position and the epsilon rate from the least common one.
+func gammaEpsilonRates(values []bitset, bitCount int) (bitset, bitset) {
+	gamma, epsilon := newBitset(bitCount), newBitset(bitCount)
+	rows := allRows(values)
+	for pos := 0; pos < bitCount; pos++ {
+		zeros, ones := zerosOnesCount(values, pos, rows)
+		if ones >= zeros {
+			gamma.set(pos)
+		} else {
+			epsilon.set(pos)
+		}
+	}
+
+	return gamma, epsilon
+}
+
+func (d *diagnostics) powerConsumption() *big.Int {
+	return new(big.Int).Mul(d.gamma.bigInt(), d.epsilon.bigInt())
+}
+
+func (d *diagnostics) lifeSupportRating() *big.Int {
+	return new(big.Int).Mul(d.oxygen.bigInt(), d.co2.bigInt())
+}
+
+func (d *diagnostics) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "gamma rate: %s (%s)\n", d.gamma, d.gamma.bigInt())
+	fmt.Fprintf(&sb, "epsilon rate: %s (%s)\n", d.epsilon, d.epsilon.bigInt())
+	fmt.Fprintf(&sb, "power consumption: %s\n", d.powerConsumption())
+	fmt.Fprintf(&sb, "oxygen generator rating: %s (%s)\n", d.oxygen, d.oxygen.bigInt())
+	fmt.Fprintf(&sb, "co2 scrubber rating: %s (%s)\n", d.co2, d.co2.bigInt())
+	fmt.Fprintf(&sb, "life support rating: %s", d.lifeSupportRating())
+	return sb.String()
+}
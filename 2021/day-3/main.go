@@ -2,12 +2,18 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
+	"math/big"
 	"os"
-	"strconv"
-)
 
-const valueBitCount = 12
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
+	"github.com/skhalash/adventofcode/internal/trace"
+)
 
 type ratingType string
 
@@ -18,22 +24,67 @@ func allRatingTypes() []ratingType {
 	return []ratingType{oxygenGeneratorRating, co2ScrubberRating}
 }
 
-type bitCriteria func(val uint64, pos, zeros, ones int) bool
+//go:embed *.go
+var source embed.FS
+
+func main() {
+	bitCount := flag.Int("bits", 0, "expected number of bits per value (inferred from the input if 0)")
+	report := flag.Bool("report", false, "print power consumption and life support diagnostics")
+	method := flag.String("method", "trie", "rating search method, scan or trie")
+	oxygenCriteria := flag.String("oxygen-criteria", "most-common", "bit criteria of the oxygen generator rating: "+bitCriteriaNames)
+	co2Criteria := flag.String("co2-criteria", "least-common", "bit criteria of the CO2 scrubber rating: "+bitCriteriaNames)
+	newTracer := trace.Flags()
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
 
-func bitCriteriaByRatingType(rt ratingType) bitCriteria {
-	switch rt {
-	case oxygenGeneratorRating:
-		return oxygenGeneratorRatingCriteria
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
 
-	case co2ScrubberRating:
-		return co2ScrubberRatingCriteria
+	tracer, err := newTracer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
 	}
 
-	panic(fmt.Sprintf("unknown rating type %s", rt))
-}
+	criteriaNames := map[ratingType]string{
+		oxygenGeneratorRating: *oxygenCriteria,
+		co2ScrubberRating:     *co2Criteria,
+	}
 
-func main() {
-	result, err := run(os.Args[1])
+	// The options only take effect on a cache miss, so check them up front
+	// rather than returning a cached answer for invalid ones.
+	if err := checkMethod(*method); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	if _, err := selectBitCriteria(criteriaNames); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	part := fmt.Sprintf("bits=%d oxygen=%s co2=%s", *bitCount, *oxygenCriteria, *co2Criteria)
+	puzzle := cache.Puzzle{Year: 2021, Day: 3, Part: part, Source: source}
+	solve := func() (interface{}, error) {
+		return run(ctx, flag.Arg(0), *bitCount, *method, criteriaNames, tracer)
+	}
+	if *report {
+		puzzle.Part = "report " + part
+		solve = func() (interface{}, error) {
+			return runReport(ctx, flag.Arg(0), *bitCount, *method, criteriaNames, tracer)
+		}
+	}
+
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache && !tracer.Enabled(), solve)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -42,101 +93,216 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (uint64, error) {
-	values, err := loadReport(filepath)
+func run(ctx context.Context, filepath string, bitCount int, method string, criteriaNames map[ratingType]string, tracer *trace.Tracer) (*big.Int, error) {
+	criteria, err := selectBitCriteria(criteriaNames)
+	if err != nil {
+		return nil, err
+	}
+
+	values, bitCount, err := loadCheckedReport(filepath, bitCount)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := newRater(method, values, bitCount, criteria, tracer)
+	if err != nil {
+		return nil, err
+	}
+
+	return ratings(ctx, rate)
+}
+
+func runReport(ctx context.Context, filepath string, bitCount int, method string, criteriaNames map[ratingType]string, tracer *trace.Tracer) (*diagnostics, error) {
+	criteria, err := selectBitCriteria(criteriaNames)
+	if err != nil {
+		return nil, err
+	}
+
+	values, bitCount, err := loadCheckedReport(filepath, bitCount)
+	if err != nil {
+		return nil, err
+	}
+
+	rate, err := newRater(method, values, bitCount, criteria, tracer)
+	if err != nil {
+		return nil, err
+	}
+
+	return newDiagnostics(ctx, values, bitCount, rate)
+}
+
+func loadCheckedReport(filepath string, bitCount int) ([]bitset, int, error) {
+	values, inferredBitCount, err := loadReport(filepath)
 	if err != nil {
-		return 0, err
+		return nil, 0, err
+	}
+
+	if bitCount != 0 && bitCount != inferredBitCount {
+		return nil, 0, fmt.Errorf("report values have %d bits, expected %d", inferredBitCount, bitCount)
+	}
+
+	if len(values) == 0 {
+		return nil, 0, fmt.Errorf("empty report")
+	}
+
+	return values, inferredBitCount, nil
+}
+
+func checkMethod(method string) error {
+	switch method {
+	case "scan", "trie":
+		return nil
+	}
+	return fmt.Errorf("unknown rating method %s", method)
+}
+
+// rater finds the value matching the bit criteria of a rating type.
+type rater func(ctx context.Context, rt ratingType) (bitset, error)
+
+func newRater(method string, values []bitset, bitCount int, criteria map[ratingType]bitCriteria, tracer *trace.Tracer) (rater, error) {
+	switch method {
+	case "scan":
+		return func(ctx context.Context, rt ratingType) (bitset, error) {
+			return scanRating(ctx, values, bitCount, rt, criteria[rt], tracer)
+		}, nil
+
+	case "trie":
+		root := newTrie(values, bitCount)
+		return func(ctx context.Context, rt ratingType) (bitset, error) {
+			return root.rating(ctx, values, bitCount, rt, criteria[rt], tracer)
+		}, nil
 	}
 
-	return ratings(values), nil
+	return nil, fmt.Errorf("unknown rating method %s", method)
 }
 
-func ratings(values []uint64) uint64 {
-	var result uint64 = 1
+func ratings(ctx context.Context, rate rater) (*big.Int, error) {
+	result := big.NewInt(1)
 
 	for _, ratingType := range allRatingTypes() {
-		bitCriteria := bitCriteriaByRatingType(ratingType)
-
-		excluded := make(map[uint64]bool)
-		for pos := valueBitCount - 1; pos >= 0; pos-- {
-			zeros, ones := zerosOnesCount(values, pos, excluded)
-
-			var lastNonExcluded uint64
-			for _, value := range values {
-				if excluded[value] {
-					continue
-				}
-
-				if !bitCriteria(value, pos, zeros, ones) {
-					excluded[value] = true
-				} else {
-					lastNonExcluded = value
-				}
-			}
+		value, err := rate(ctx, ratingType)
+		if err != nil {
+			return nil, err
+		}
+		result.Mul(result, value.bigInt())
+	}
+
+	return result, nil
+}
+
+// scanRating filters the report one bit position at a time, rescanning the
+// remaining values at every position.
+func scanRating(ctx context.Context, values []bitset, bitCount int, ratingType ratingType, bitCriteria bitCriteria, tracer *trace.Tracer) (bitset, error) {
+	// Rows are tracked by index rather than by value, so that duplicate
+	// values in the report are counted as many times as they occur.
+	remaining := allRows(values)
+	for pos := bitCount - 1; pos >= 0 && len(remaining) > 1; pos-- {
+		if err := timeout.Check(ctx); err != nil {
+			return bitset{}, err
+		}
+
+		zeros, ones := zerosOnesCount(values, pos, remaining)
+		bit := bitCriteria(zeros, ones)
 
-			if len(values)-len(excluded) == 1 {
-				result *= lastNonExcluded
-				break
+		var matching []int
+		for _, row := range remaining {
+			if values[row].bit(pos) == bit {
+				matching = append(matching, row)
 			}
 		}
+		remaining = matching
+
+		if tracer.Enabled() {
+			tracer.Event("filter",
+				trace.F("rating", ratingType),
+				trace.F("pos", pos),
+				trace.F("zeros", zeros),
+				trace.F("ones", ones),
+				trace.F("candidates", candidates(values, remaining)))
+		}
+	}
+
+	// Rows left after all bit positions are equal, so any of them is the rating.
+	if len(remaining) == 0 {
+		return bitset{}, fmt.Errorf("no value matches the %s criteria", ratingType)
 	}
+	return values[remaining[0]], nil
+}
 
+func allRows(values []bitset) []int {
+	result := make([]int, len(values))
+	for i := range values {
+		result[i] = i
+	}
 	return result
 }
 
-func zerosOnesCount(values []uint64, pos int, excluded map[uint64]bool) (zeros, ones int) {
-	var mask uint64 = 1 << pos
-	for _, v := range values {
-		if _, exists := excluded[v]; exists {
-			continue
-		}
+func candidates(values []bitset, rows []int) []string {
+	var result []string
+	for _, row := range rows {
+		result = append(result, values[row].String())
+	}
+	return result
+}
 
-		if v&mask == 0 {
-			zeros++
-		} else {
+func zerosOnesCount(values []bitset, pos int, rows []int) (zeros, ones int) {
+	for _, row := range rows {
+		if values[row].bit(pos) {
 			ones++
+		} else {
+			zeros++
 		}
 	}
 
 	return
 }
 
-func oxygenGeneratorRatingCriteria(value uint64, pos, zeros, ones int) bool {
-	var mostCommonBit uint64
-	if ones >= zeros {
-		mostCommonBit = 1
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
 	}
 
-	return mostCommonBit<<pos == value&(1<<pos)
-}
-
-func co2ScrubberRatingCriteria(value uint64, pos, zeros, ones int) bool {
-	var leastCommonBit uint64 = 1
-	if ones >= zeros {
-		leastCommonBit = 0
+	values, bitCount, err := loadReport(filepath)
+	if err != nil {
+		return err
 	}
 
-	return leastCommonBit<<pos == value&(1<<pos)
+	inspect.Print("lines", lineCount)
+	inspect.Print("values", len(values))
+	inspect.Print("bits", bitCount)
+	return nil
 }
 
-func loadReport(filepath string) ([]uint64, error) {
+func loadReport(filepath string) ([]bitset, int, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
-		return nil, fmt.Errorf("failed to open data file: %v", err)
+		return nil, 0, fmt.Errorf("failed to open data file: %v", err)
 	}
 
-	var result []uint64
+	var result []bitset
+	bitCount := 0
 
+	lineNumber := 0
 	scanner := bufio.NewScanner(file)
 	for scanner.Scan() {
+		lineNumber++
 		line := scanner.Text()
-		i, err := strconv.ParseUint(line, 2, 64)
+		value, err := parseBitset(line)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse line %s: %v", line, err)
+			return nil, 0, fmt.Errorf("failed to parse line %d %s: %v", lineNumber, line, err)
 		}
 
-		result = append(result, i)
+		if bitCount == 0 {
+			bitCount = value.width
+		}
+
+		if value.width == 0 || value.width != bitCount {
+			return nil, 0, fmt.Errorf("line %d has %d bits, expected %d", lineNumber, value.width, bitCount)
+		}
+
+		result = append(result, value)
 	}
 
-	return result, nil
+	return result, bitCount, nil
 }
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRatingMethods(t *testing.T) {
+	tests := []struct {
+		name   string
+		report []string
+		want   int64
+	}{
+		{
+			name:   "duplicate rows",
+			report: []string{"101", "101", "101", "010"},
+			want:   10,
+		},
+		{
+			name:   "identical rows",
+			report: []string{"10110", "10110"},
+			want:   484,
+		},
+		{
+			name:   "example",
+			report: []string{"00100", "11110", "10110", "10111", "10101", "01111", "00111", "11100", "10000", "11001", "00010", "01010"},
+			want:   230,
+		},
+	}
+
+	criteria, err := selectBitCriteria(map[ratingType]string{
+		oxygenGeneratorRating: "most-common",
+		co2ScrubberRating:     "least-common",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var values []bitset
+			for _, s := range tt.report {
+				v, err := parseBitset(s)
+				if err != nil {
+					t.Fatalf("parseBitset(%q) failed: %v", s, err)
+				}
+				values = append(values, v)
+			}
+			bitCount := values[0].width
+
+			results := make(map[string]int64)
+			for _, method := range []string{"scan", "trie"} {
+				rate, err := newRater(method, values, bitCount, criteria, nil)
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				got, err := ratings(context.Background(), rate)
+				if err != nil {
+					t.Fatalf("%s: %v", method, err)
+				}
+				if got.Int64() != tt.want {
+					t.Errorf("%s: got %s, want %d", method, got, tt.want)
+				}
+				results[method] = got.Int64()
+			}
+
+			if results["scan"] != results["trie"] {
+				t.Errorf("methods disagree: scan %d, trie %d", results["scan"], results["trie"])
+			}
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/skhalash/adventofcode/internal/timeout"
+	"github.com/skhalash/adventofcode/internal/trace"
+)
+
+// trieNode is a node of a binary trie over the report values, starting at the
+// most significant bit. Each node knows how many rows share its prefix, so the
+// zero and one counts at a bit position are read off the children instead of
+// rescanning the report.
+type trieNode struct {
+	count    int
+	row      int
+	children [2]*trieNode
+}
+
+func newTrie(values []bitset, bitCount int) *trieNode {
+	root := &trieNode{}
+	for row, v := range values {
+		node := root
+		node.count++
+		node.row = row
+		for pos := bitCount - 1; pos >= 0; pos-- {
+			child := &node.children[bitIndex(v.bit(pos))]
+			if *child == nil {
+				*child = &trieNode{}
+			}
+
+			node = *child
+			node.count++
+			node.row = row
+		}
+	}
+
+	return root
+}
+
+func (n *trieNode) childCount(bit bool) int {
+	if child := n.children[bitIndex(bit)]; child != nil {
+		return child.count
+	}
+	return 0
+}
+
+// rating walks down the trie following the bit criteria. Once a single row
+// is left, or all bits are consumed, any row below the node is the rating.
+func (n *trieNode) rating(ctx context.Context, values []bitset, bitCount int, ratingType ratingType, bitCriteria bitCriteria, tracer *trace.Tracer) (bitset, error) {
+	node := n
+	var prefix []bool
+	for pos := bitCount - 1; pos >= 0 && node.count > 1; pos-- {
+		if err := timeout.Check(ctx); err != nil {
+			return bitset{}, err
+		}
+
+		zeros, ones := node.childCount(false), node.childCount(true)
+		bit := bitCriteria(zeros, ones)
+
+		node = node.children[bitIndex(bit)]
+		if node == nil {
+			return bitset{}, fmt.Errorf("no value matches the %s criteria", ratingType)
+		}
+
+		prefix = append(prefix, bit)
+		if tracer.Enabled() {
+			tracer.Event("filter",
+				trace.F("rating", ratingType),
+				trace.F("pos", pos),
+				trace.F("zeros", zeros),
+				trace.F("ones", ones),
+				trace.F("candidates", prefixCandidates(values, bitCount, prefix)))
+		}
+	}
+
+	if node.count == 0 {
+		return bitset{}, fmt.Errorf("no value matches the %s criteria", ratingType)
+	}
+	return values[node.row], nil
+}
+
+// prefixCandidates lists the values below a trie node. The trie doesn't keep
+// them, so this rescans the report and is only meant for tracing.
+func prefixCandidates(values []bitset, bitCount int, prefix []bool) []string {
+	var result []string
+	for _, v := range values {
+		matches := true
+		for i, bit := range prefix {
+			if v.bit(bitCount-1-i) != bit {
+				matches = false
+				break
+			}
+		}
+
+		if matches {
+			result = append(result, v.String())
+		}
+	}
+	return result
+}
+
+func bitIndex(bit bool) int {
+	if bit {
+		return 1
+	}
+	return 0
+}
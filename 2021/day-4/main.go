@@ -2,93 +2,232 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
-)
 
-const boardSize = 5
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
+	"github.com/skhalash/adventofcode/internal/trace"
+)
 
 type bingo struct {
-	numbers          []int
-	boards           []*board
-	winnerBoardCount int
+	numbers []int
+	boards  []*board
+	index   map[int][]placement
+	tracer  *trace.Tracer
 }
 
-func (b *bingo) play() int {
-	for _, n := range b.numbers {
-		for _, board := range b.boards {
+// placement lists the cells of a board holding a number.
+type placement struct {
+	board int
+	cells []int
+}
+
+// buildIndex maps every number to the boards and cells holding it, so that
+// a draw only touches the affected cells instead of scanning every board.
+func (b *bingo) buildIndex() {
+	b.index = make(map[int][]placement)
+	for j, board := range b.boards {
+		cellsByNumber := make(map[int][]int)
+		var numbers []int
+		for i, n := range board.cells {
+			if _, found := cellsByNumber[n]; !found {
+				numbers = append(numbers, n)
+			}
+			cellsByNumber[n] = append(cellsByNumber[n], i)
+		}
+
+		for _, n := range numbers {
+			b.index[n] = append(b.index[n], placement{board: j, cells: cellsByNumber[n]})
+		}
+	}
+}
+
+// placements returns the cells holding a number in board order, from the
+// index if it has been built and by scanning every board otherwise.
+func (b *bingo) placements(n int) []placement {
+	if b.index != nil {
+		return b.index[n]
+	}
+
+	var result []placement
+	for j, board := range b.boards {
+		if board.isWinner() {
+			continue
+		}
+
+		var cells []int
+		for i, cell := range board.cells {
+			if cell == n {
+				cells = append(cells, i)
+			}
+		}
+		if len(cells) > 0 {
+			result = append(result, placement{board: j, cells: cells})
+		}
+	}
+	return result
+}
+
+// play draws the header numbers until every board has won or the numbers run
+// out.
+func (b *bingo) play(ctx context.Context) (*outcome, error) {
+	return b.playNumbers(ctx, b.numbers)
+}
+
+// playNumbers clears all marks and plays a game with the given draw order, so
+// that the same boards can be played any number of times.
+func (b *bingo) playNumbers(ctx context.Context, numbers []int) (*outcome, error) {
+	for _, board := range b.boards {
+		board.reset()
+	}
+
+	var o outcome
+	for i, n := range numbers {
+		if err := timeout.Check(ctx); err != nil {
+			return nil, err
+		}
+
+		b.tracer.Event("draw", trace.F("draw", i+1), trace.F("number", n))
+		for _, p := range b.placements(n) {
+			board := b.boards[p.board]
 			if board.isWinner() {
 				continue
 			}
 
-			board.mark(n)
+			board.mark(n, p.cells)
 			if board.isWinner() {
-				b.winnerBoardCount++
-				if b.winnerBoardCount == len(b.boards) {
-					return board.score()
+				f := finish{
+					board:  p.board + 1,
+					place:  len(o.finishes) + 1,
+					number: n,
+					draw:   i + 1,
+					score:  board.score(),
 				}
+				o.finishes = append(o.finishes, f)
+				b.tracer.Event("win",
+					trace.F("board", f.board),
+					trace.F("number", f.number),
+					trace.F("place", f.place),
+					trace.F("score", f.score))
 			}
 		}
+
+		if len(o.finishes) == len(b.boards) {
+			break
+		}
+	}
+
+	for j, board := range b.boards {
+		if !board.isWinner() {
+			o.neverWon = append(o.neverWon, j+1)
+		}
 	}
 
-	return 0
+	return &o, nil
+}
+
+func (b *bingo) setWinPattern(pattern winPattern) error {
+	for i, board := range b.boards {
+		masks, err := pattern(board.rows, board.columns)
+		if err != nil {
+			return fmt.Errorf("board %d: %v", i+1, err)
+		}
+		board.setWinMasks(masks)
+	}
+	return nil
 }
 
 type board struct {
-	cells                []int
-	marked               []bool
-	lastMarked           int
-	markedCountPerRow    []int
-	markedCountPerColumn []int
+	rows          int
+	columns       int
+	cells         []int
+	marked        []bool
+	lastMarked    int
+	masks         [][]int
+	masksByCell   [][]int
+	markedPerMask []int
+	won           bool
 }
 
 func newBoard() *board {
-	size := boardSize
-	return &board{
-		cells:                make([]int, 0, size*size),
-		marked:               make([]bool, size*size),
-		markedCountPerRow:    make([]int, size),
-		markedCountPerColumn: make([]int, size),
+	return &board{}
+}
+
+func (b *board) addRow(cells []int) error {
+	if b.rows > 0 && len(cells) != b.columns {
+		return fmt.Errorf("board row has %d cells, previous rows have %d", len(cells), b.columns)
+	}
+
+	b.rows++
+	b.columns = len(cells)
+	b.cells = append(b.cells, cells...)
+	b.marked = append(b.marked, make([]bool, len(cells))...)
+	return nil
+}
+
+// setWinMasks sets the cell groups that win once fully marked.
+func (b *board) setWinMasks(masks [][]int) {
+	b.masks = masks
+	b.masksByCell = make([][]int, len(b.cells))
+	for m, mask := range masks {
+		for _, cell := range mask {
+			b.masksByCell[cell] = append(b.masksByCell[cell], m)
+		}
 	}
+	b.markedPerMask = make([]int, len(masks))
 }
 
-func (b *board) isValid() bool {
-	return len(b.cells) == boardSize*boardSize
+func (b *board) reset() {
+	for i := range b.marked {
+		b.marked[i] = false
+	}
+	for m := range b.markedPerMask {
+		b.markedPerMask[m] = 0
+	}
+	b.lastMarked = 0
+	b.won = false
 }
 
-func (b *board) mark(n int) {
-	for i := range b.cells {
-		if b.cells[i] != n || b.marked[i] {
+// mark marks the given cells, all of which hold the drawn number n.
+func (b *board) mark(n int, cells []int) {
+	for _, i := range cells {
+		if b.marked[i] {
 			continue
 		}
 
 		b.marked[i] = true
-		row := i / boardSize
-		column := i % boardSize
-		b.markedCountPerRow[row]++
-		b.markedCountPerColumn[column]++
+		for _, m := range b.masksByCell[i] {
+			b.markedPerMask[m]++
+			if b.markedPerMask[m] == len(b.masks[m]) {
+				b.won = true
+			}
+		}
 		b.lastMarked = n
 	}
 }
 
-func (b *board) isWinner() bool {
-	for _, count := range b.markedCountPerRow {
-		if count == boardSize {
+func (b *board) hasDuplicateCells() bool {
+	seen := make(map[int]bool)
+	for _, cell := range b.cells {
+		if seen[cell] {
 			return true
 		}
+		seen[cell] = true
 	}
-
-	for _, count := range b.markedCountPerColumn {
-		if count == boardSize {
-			return true
-		}
-	}
-
 	return false
 }
 
+func (b *board) isWinner() bool {
+	return b.won
+}
+
 func (b *board) score() int {
 	unmarkedSum := 0
 	for i, marked := range b.marked {
@@ -100,8 +239,77 @@ func (b *board) score() int {
 	return unmarkedSum * b.lastMarked
 }
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	boardSize := flag.Int("board-size", 0, "expected side length of every board (boards may have any dimensions if 0)")
+	distinctCells := flag.Bool("distinct-cells", false, "reject boards holding the same number more than once")
+	report := flag.Bool("report", false, "print the finishing order of all boards")
+	winSpec := flag.String("win", "lines", "comma separated win patterns: "+winPatternNames)
+	maskPath := flag.String("win-masks", "", "file with custom win masks drawn with x and ., added to the -win patterns")
+	games := flag.Int("simulate", 0, "print win probabilities of every board over the given number of random draw orders")
+	seed := flag.Int64("seed", 1, "random seed of -simulate")
+	newTracer := trace.Flags()
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	rules := inputRules{boardSize: *boardSize, distinctCells: *distinctCells}
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0), rules); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	tracer, err := newTracer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	pattern, err := newWinPattern(*winSpec, *maskPath)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	part := fmt.Sprintf("%s win=%s", rules, *winSpec)
+	if *maskPath != "" {
+		maskHash, err := cache.HashFile(*maskPath)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		part += " masks=" + maskHash
+	}
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 4, Part: part, Source: source}
+	solve := func() (interface{}, error) {
+		return run(ctx, flag.Arg(0), rules, pattern, tracer)
+	}
+	if *report {
+		puzzle.Part = "report " + puzzle.Part
+		solve = func() (interface{}, error) {
+			return runReport(ctx, flag.Arg(0), rules, pattern, tracer)
+		}
+	}
+
+	if *games > 0 {
+		puzzle.Part = fmt.Sprintf("simulate games=%d seed=%d %s", *games, *seed, puzzle.Part)
+		solve = func() (interface{}, error) {
+			return runSimulation(ctx, flag.Arg(0), rules, pattern, *games, *seed)
+		}
+	}
+
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache && !tracer.Enabled(), solve)
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -110,63 +318,202 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
-	bingo, err := loadBingo(filepath)
+func newWinPattern(spec, maskPath string) (winPattern, error) {
+	pattern, err := parseWinPatterns(spec)
+	if err != nil {
+		return nil, err
+	}
+
+	if maskPath == "" {
+		return pattern, nil
+	}
+
+	masks, err := loadMaskPattern(maskPath)
+	if err != nil {
+		return nil, err
+	}
+	return combinePatterns(pattern, masks), nil
+}
+
+func run(ctx context.Context, filepath string, rules inputRules, pattern winPattern, tracer *trace.Tracer) (int, error) {
+	o, err := runReport(ctx, filepath, rules, pattern, tracer)
 	if err != nil {
 		return 0, err
 	}
 
-	return bingo.play(), nil
+	last, found := o.last()
+	if !found {
+		return 0, nil
+	}
+	return last.score, nil
+}
+
+func runReport(ctx context.Context, filepath string, rules inputRules, pattern winPattern, tracer *trace.Tracer) (*outcome, error) {
+	bingo, err := loadBingo(filepath, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bingo.setWinPattern(pattern); err != nil {
+		return nil, err
+	}
+
+	bingo.tracer = tracer
+	bingo.buildIndex()
+
+	return bingo.play(ctx)
+}
+
+func runSimulation(ctx context.Context, filepath string, rules inputRules, pattern winPattern, games int, seed int64) (*simulation, error) {
+	bingo, err := loadBingo(filepath, rules)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := bingo.setWinPattern(pattern); err != nil {
+		return nil, err
+	}
+
+	bingo.buildIndex()
+
+	return bingo.simulate(ctx, games, seed)
+}
+
+func summarize(filepath string, rules inputRules) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	bingo, err := loadBingo(filepath, rules)
+	if err != nil {
+		return err
+	}
+
+	inspect.Print("lines", lineCount)
+	inspect.Print("numbers", len(bingo.numbers))
+	inspect.Print("boards", len(bingo.boards))
+	boardCountByDimensions := make(map[string]int)
+	var dimensions []string
+	for _, b := range bingo.boards {
+		d := fmt.Sprintf("%dx%d", b.rows, b.columns)
+		if boardCountByDimensions[d] == 0 {
+			dimensions = append(dimensions, d)
+		}
+		boardCountByDimensions[d]++
+	}
+
+	for _, d := range dimensions {
+		inspect.Print(d+" boards", boardCountByDimensions[d])
+	}
+
+	duplicateCellBoards := 0
+	for _, b := range bingo.boards {
+		if b.hasDuplicateCells() {
+			duplicateCellBoards++
+		}
+	}
+	inspect.Print("boards with duplicate cells", duplicateCellBoards)
+	return nil
 }
 
 const numberSeparator = ","
 const boardSeparator = ""
 
-func loadBingo(filepath string) (*bingo, error) {
+// inputRules are the optional checks applied while loading the input.
+type inputRules struct {
+	boardSize     int
+	distinctCells bool
+}
+
+func (r inputRules) String() string {
+	return fmt.Sprintf("board-size=%d distinct-cells=%t", r.boardSize, r.distinctCells)
+}
+
+func loadBingo(filepath string, rules inputRules) (*bingo, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open data file: %v", err)
 	}
+	defer file.Close()
 
 	var bingo bingo
 
 	scanner := bufio.NewScanner(file)
-	scanner.Scan()
-	header := scanner.Text()
-	for _, s := range strings.Split(header, numberSeparator) {
+	if !scanner.Scan() || strings.TrimSpace(scanner.Text()) == "" {
+		if err := scanner.Err(); err != nil {
+			return nil, fmt.Errorf("failed to read data file: %v", err)
+		}
+		return nil, fmt.Errorf("line 1: missing header with the drawn numbers")
+	}
+
+	for _, s := range strings.Split(scanner.Text(), numberSeparator) {
 		number, err := strconv.Atoi(s)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse number %s: %v", s, err)
+			return nil, fmt.Errorf("line 1: failed to parse number %s: %v", s, err)
 		}
 		bingo.numbers = append(bingo.numbers, number)
 	}
 
+	if scanner.Scan() && scanner.Text() != boardSeparator {
+		return nil, fmt.Errorf("line 2: expected a blank line after the header, found %q", scanner.Text())
+	}
+
+	lineNumber := 2
 	var last *board
+	// cellLines maps the numbers on the last board to the line they are on.
+	var cellLines map[int]int
 	for scanner.Scan() {
+		lineNumber++
 		line := scanner.Text()
 
 		if line == boardSeparator {
-			if last != nil && last.isValid() {
+			if last != nil {
 				bingo.boards = append(bingo.boards, last)
 			}
-			last = newBoard()
+			last = nil
 			continue
 		}
 
+		if last == nil {
+			last = newBoard()
+			cellLines = make(map[int]int)
+		}
+
+		var row []int
 		for _, s := range strings.Fields(line) {
 			cell, err := strconv.Atoi(s)
 			if err != nil {
+				return nil, fmt.Errorf("line %d: failed to parse board cell %s: %v", lineNumber, s, err)
+			}
 
-				return nil, fmt.Errorf("failed to parse board cell %s: %v", s, err)
+			if previous, found := cellLines[cell]; found && rules.distinctCells {
+				return nil, fmt.Errorf("line %d: number %d is already on board %d at line %d", lineNumber, cell, len(bingo.boards)+1, previous)
 			}
+			cellLines[cell] = lineNumber
+			row = append(row, cell)
+		}
 
-			last.cells = append(last.cells, cell)
+		if err := last.addRow(row); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNumber, err)
 		}
 	}
 
-	if last != nil && last.isValid() {
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read data file: %v", err)
+	}
+
+	if last != nil {
 		bingo.boards = append(bingo.boards, last)
 	}
 
+	if rules.boardSize != 0 {
+		for i, b := range bingo.boards {
+			if b.rows != rules.boardSize || b.columns != rules.boardSize {
+				return nil, fmt.Errorf("board %d is %dx%d, expected %dx%d", i+1, b.rows, b.columns, rules.boardSize, rules.boardSize)
+			}
+		}
+	}
+
 	return &bingo, nil
 }
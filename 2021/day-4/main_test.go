@@ -0,0 +1,55 @@
+package main
+
+import (
+	"context"
+	"math/rand"
+	"testing"
+)
+
+func BenchmarkPlayScan(b *testing.B) {
+	game := randomBingo(b, 5000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := game.play(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkPlayIndexed(b *testing.B) {
+	game := randomBingo(b, 5000)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		game.buildIndex()
+		if _, err := game.play(context.Background()); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// randomBingo draws the numbers below 1000 in random order, with every 5x5
+// board holding distinct numbers from the same range.
+func randomBingo(b *testing.B, boardCount int) *bingo {
+	const numberRange = 1000
+	const size = 5
+
+	rnd := rand.New(rand.NewSource(1))
+	game := &bingo{numbers: rnd.Perm(numberRange)}
+	for i := 0; i < boardCount; i++ {
+		cells := rnd.Perm(numberRange)
+		board := newBoard()
+		for r := 0; r < size; r++ {
+			if err := board.addRow(cells[r*size : (r+1)*size]); err != nil {
+				b.Fatal(err)
+			}
+		}
+		game.boards = append(game.boards, board)
+	}
+
+	if err := game.setWinPattern(combinePatterns(rowsPattern, columnsPattern)); err != nil {
+		b.Fatal(err)
+	}
+	return game
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// finish records when a board won. Boards and draws are numbered from 1.
+type finish struct {
+	board  int
+	place  int
+	number int
+	draw   int
+	score  int
+}
+
+func (f finish) String() string {
+	return fmt.Sprintf("board %d, draw %d (number %d), score %d", f.board, f.draw, f.number, f.score)
+}
+
+type outcome struct {
+	finishes []finish
+	neverWon []int
+}
+
+func (o *outcome) first() (finish, bool) {
+	if len(o.finishes) == 0 {
+		return finish{}, false
+	}
+	return o.finishes[0], true
+}
+
+func (o *outcome) last() (finish, bool) {
+	if len(o.finishes) == 0 {
+		return finish{}, false
+	}
+	return o.finishes[len(o.finishes)-1], true
+}
+
+// finishedAt returns the boards that won on the given draw.
+func (o *outcome) finishedAt(draw int) []finish {
+	var result []finish
+	for _, f := range o.finishes {
+		if f.draw == draw {
+			result = append(result, f)
+		}
+	}
+	return result
+}
+
+func (o *outcome) String() string {
+	var sb strings.Builder
+	if first, found := o.first(); found {
+		fmt.Fprintf(&sb, "first winner: %s\n", first)
+	}
+	if last, found := o.last(); found {
+		fmt.Fprintf(&sb, "last winner: %s\n", last)
+	}
+
+	sb.WriteString("finishing order:")
+	for _, f := range o.finishes {
+		fmt.Fprintf(&sb, "\n  %d. %s", f.place, f)
+	}
+
+	if len(o.neverWon) > 0 {
+		var boards []string
+		for _, b := range o.neverWon {
+			boards = append(boards, fmt.Sprint(b))
+		}
+		fmt.Fprintf(&sb, "\nnever won: boards %s", strings.Join(boards, ", "))
+	}
+
+	return sb.String()
+}
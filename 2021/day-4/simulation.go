@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"math/rand"
+	"strings"
+)
+
+// boardStats counts how a board fared over all simulated games.
+// Boards finishing together share the credit for winning first or last.
+type boardStats struct {
+	firstWins float64
+	lastWins  float64
+	wins      int
+	draws     int
+}
+
+type simulation struct {
+	games  int
+	seed   int64
+	boards []boardStats
+}
+
+// simulate plays the given number of games, each drawing the header numbers
+// in a random order. The last winner of a game is the last board to win among
+// those that won at all.
+func (b *bingo) simulate(ctx context.Context, games int, seed int64) (*simulation, error) {
+	rnd := rand.New(rand.NewSource(seed))
+	order := append([]int(nil), b.numbers...)
+
+	s := &simulation{games: games, seed: seed, boards: make([]boardStats, len(b.boards))}
+	for g := 0; g < games; g++ {
+		rnd.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+
+		o, err := b.playNumbers(ctx, order)
+		if err != nil {
+			return nil, err
+		}
+
+		if first, found := o.first(); found {
+			tied := o.finishedAt(first.draw)
+			for _, f := range tied {
+				s.boards[f.board-1].firstWins += 1 / float64(len(tied))
+			}
+		}
+		if last, found := o.last(); found {
+			tied := o.finishedAt(last.draw)
+			for _, f := range tied {
+				s.boards[f.board-1].lastWins += 1 / float64(len(tied))
+			}
+		}
+		for _, f := range o.finishes {
+			s.boards[f.board-1].wins++
+			s.boards[f.board-1].draws += f.draw
+		}
+	}
+
+	return s, nil
+}
+
+func (s *simulation) String() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "games: %d, seed: %d", s.games, s.seed)
+	for i, stats := range s.boards {
+		fmt.Fprintf(&sb, "\nboard %d: first %.2f%%, last %.2f%%, wins %.2f%%, expected draws to win ",
+			i+1, s.percentage(stats.firstWins), s.percentage(stats.lastWins), s.percentage(float64(stats.wins)))
+		if stats.wins == 0 {
+			sb.WriteString("-")
+		} else {
+			fmt.Fprintf(&sb, "%.2f", float64(stats.draws)/float64(stats.wins))
+		}
+	}
+	return sb.String()
+}
+
+func (s *simulation) percentage(count float64) float64 {
+	return 100 * count / float64(s.games)
+}
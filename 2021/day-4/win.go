@@ -0,0 +1,217 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// winPattern returns the masks of a board with the given dimensions. A mask
+// lists cell indices, and a board wins once all cells of any mask are marked.
+type winPattern func(rows, columns int) ([][]int, error)
+
+const patternSeparator = ","
+const winPatternNames = "rows, columns, lines, diagonals, corners, full or x"
+
+func winPatternByName(name string) (winPattern, bool) {
+	switch name {
+	case "rows":
+		return rowsPattern, true
+	case "columns":
+		return columnsPattern, true
+	case "lines":
+		return combinePatterns(rowsPattern, columnsPattern), true
+	case "diagonals":
+		return diagonalsPattern, true
+	case "corners":
+		return cornersPattern, true
+	case "full":
+		return fullPattern, true
+	case "x":
+		return xPattern, true
+	}
+	return nil, false
+}
+
+// parseWinPatterns resolves a comma separated list of pattern names.
+func parseWinPatterns(spec string) (winPattern, error) {
+	var patterns []winPattern
+	for _, name := range strings.Split(spec, patternSeparator) {
+		p, found := winPatternByName(name)
+		if !found {
+			return nil, fmt.Errorf("unknown win pattern %s, must be %s", name, winPatternNames)
+		}
+		patterns = append(patterns, p)
+	}
+	return combinePatterns(patterns...), nil
+}
+
+func combinePatterns(patterns ...winPattern) winPattern {
+	return func(rows, columns int) ([][]int, error) {
+		var result [][]int
+		for _, p := range patterns {
+			masks, err := p(rows, columns)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, masks...)
+		}
+		return result, nil
+	}
+}
+
+func rowsPattern(rows, columns int) ([][]int, error) {
+	var result [][]int
+	for r := 0; r < rows; r++ {
+		var mask []int
+		for c := 0; c < columns; c++ {
+			mask = append(mask, r*columns+c)
+		}
+		result = append(result, mask)
+	}
+	return result, nil
+}
+
+func columnsPattern(rows, columns int) ([][]int, error) {
+	var result [][]int
+	for c := 0; c < columns; c++ {
+		var mask []int
+		for r := 0; r < rows; r++ {
+			mask = append(mask, r*columns+c)
+		}
+		result = append(result, mask)
+	}
+	return result, nil
+}
+
+func diagonalsPattern(rows, columns int) ([][]int, error) {
+	if rows != columns {
+		return nil, fmt.Errorf("diagonals need a square board, got %dx%d", rows, columns)
+	}
+
+	var main, anti []int
+	for i := 0; i < rows; i++ {
+		main = append(main, i*columns+i)
+		anti = append(anti, i*columns+columns-1-i)
+	}
+	return [][]int{main, anti}, nil
+}
+
+func cornersPattern(rows, columns int) ([][]int, error) {
+	return [][]int{uniqueCells(0, columns-1, (rows-1)*columns, rows*columns-1)}, nil
+}
+
+func fullPattern(rows, columns int) ([][]int, error) {
+	var mask []int
+	for i := 0; i < rows*columns; i++ {
+		mask = append(mask, i)
+	}
+	return [][]int{mask}, nil
+}
+
+// xPattern requires both diagonals at once.
+func xPattern(rows, columns int) ([][]int, error) {
+	diagonals, err := diagonalsPattern(rows, columns)
+	if err != nil {
+		return nil, err
+	}
+	return [][]int{uniqueCells(append(diagonals[0], diagonals[1]...)...)}, nil
+}
+
+func uniqueCells(cells ...int) []int {
+	seen := make(map[int]bool)
+	var result []int
+	for _, c := range cells {
+		if !seen[c] {
+			seen[c] = true
+			result = append(result, c)
+		}
+	}
+	return result
+}
+
+const maskCell = 'x'
+const maskEmpty = '.'
+const maskComment = "#"
+
+// loadMaskPattern reads custom masks drawn as grids of x (cell required) and
+// . (cell ignored), separated by blank lines. Lines starting with # are
+// comments. Masks only apply to boards of the same dimensions, and every
+// board needs at least one mask that fits it.
+func loadMaskPattern(filepath string) (winPattern, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open mask file: %v", err)
+	}
+	defer file.Close()
+
+	type mask struct {
+		rows, columns int
+		cells         []int
+	}
+
+	var masks []mask
+	var last *mask
+
+	lineNumber := 0
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, maskComment) {
+			continue
+		}
+
+		if line == "" {
+			last = nil
+			continue
+		}
+
+		if last == nil {
+			masks = append(masks, mask{columns: len(line)})
+			last = &masks[len(masks)-1]
+		}
+
+		if len(line) != last.columns {
+			return nil, fmt.Errorf("mask line %d has %d columns, expected %d", lineNumber, len(line), last.columns)
+		}
+
+		for c, char := range line {
+			switch char {
+			case maskCell:
+				last.cells = append(last.cells, last.rows*last.columns+c)
+			case maskEmpty:
+			default:
+				return nil, fmt.Errorf("mask line %d: invalid character %c", lineNumber, char)
+			}
+		}
+		last.rows++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read mask file: %v", err)
+	}
+
+	if len(masks) == 0 {
+		return nil, fmt.Errorf("no masks in %s", filepath)
+	}
+
+	return func(rows, columns int) ([][]int, error) {
+		var result [][]int
+		fits := false
+		for _, m := range masks {
+			if m.rows != rows || m.columns != columns {
+				continue
+			}
+			fits = true
+			if len(m.cells) > 0 {
+				result = append(result, m.cells)
+			}
+		}
+		if !fits {
+			return nil, fmt.Errorf("no mask fits a %dx%d board", rows, columns)
+		}
+		return result, nil
+	}, nil
+}
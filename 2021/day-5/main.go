@@ -2,11 +2,18 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"math"
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
 )
 
 type point struct {
@@ -180,8 +187,30 @@ func (vlg *ventLineGrid) dangerRate() int {
 	return rate
 }
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 5, Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache, func() (interface{}, error) {
+		return run(ctx, flag.Arg(0))
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -190,16 +219,39 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string) (int, error) {
 	lines, err := loadVentLines(filepath)
 	if err != nil {
 		return 0, err
 	}
 
+	if err := timeout.Check(ctx); err != nil {
+		return 0, err
+	}
+
 	grid := newVentLineGrid(lines)
 	return grid.dangerRate(), nil
 }
 
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	lines, err := loadVentLines(filepath)
+	if err != nil {
+		return err
+	}
+
+	origin, end := gridCorners(lines)
+	inspect.Print("lines", lineCount)
+	inspect.Print("vent lines", len(lines))
+	inspect.Print("supported vent lines", len(validate(lines)))
+	inspect.Print("bounding box", fmt.Sprintf("%d,%d -> %d,%d", origin.x, origin.y, end.x, end.y))
+	return nil
+}
+
 func loadVentLines(filepath string) ([]ventLine, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -1,17 +1,45 @@
 package main
 
 import (
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
 )
 
-const dayCount = 256
+//go:embed *.go
+var source embed.FS
 
 func main() {
-	result, err := run(os.Args[1])
+	dayCount := flag.Int("days", 256, "number of days to simulate")
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 6, Part: fmt.Sprintf("days=%d", *dayCount), Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache, func() (interface{}, error) {
+		return run(ctx, flag.Arg(0), *dayCount)
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -20,7 +48,7 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string, dayCount int) (int, error) {
 	lanternfishDaysLeft, err := loadLanternfishDaysLeft(filepath)
 	if err != nil {
 		return 0, err
@@ -32,6 +60,10 @@ func run(filepath string) (int, error) {
 	}
 
 	for i := 0; i < dayCount; i++ {
+		if err := timeout.Check(ctx); err != nil {
+			return 0, err
+		}
+
 		nextDay(countByDaysLeft)
 	}
 
@@ -54,6 +86,24 @@ func nextDay(countByDaysLeft []int) {
 	countByDaysLeft[8] = newbornCount
 }
 
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	lanternfishDaysLeft, err := loadLanternfishDaysLeft(filepath)
+	if err != nil {
+		return err
+	}
+
+	min, max := inspect.Range(lanternfishDaysLeft)
+	inspect.Print("lines", lineCount)
+	inspect.Print("lanternfish", len(lanternfishDaysLeft))
+	inspect.Print("days left range", fmt.Sprintf("%d..%d", min, max))
+	return nil
+}
+
 func loadLanternfishDaysLeft(filepath string) ([]int, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
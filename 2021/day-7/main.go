@@ -1,16 +1,45 @@
 package main
 
 import (
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"io/ioutil"
 	"math"
 	"os"
 	"strconv"
 	"strings"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
 )
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 7, Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache, func() (interface{}, error) {
+		return run(ctx, flag.Arg(0))
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -19,7 +48,7 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string) (int, error) {
 	crabPositions, err := loadCrabPositions(filepath)
 	if err != nil {
 		return 0, err
@@ -30,13 +59,17 @@ func run(filepath string) (int, error) {
 		crabCountByPosition[pos]++
 	}
 
-	return alignCrabs(crabCountByPosition), nil
+	return alignCrabs(ctx, crabCountByPosition)
 }
 
-func alignCrabs(crabCountByPosition map[int]int) int {
+func alignCrabs(ctx context.Context, crabCountByPosition map[int]int) (int, error) {
 	minPos, maxPos := minMaxPosition(crabCountByPosition)
 	minFuel := math.MaxInt
 	for i := minPos; i < maxPos; i++ {
+		if err := timeout.Check(ctx); err != nil {
+			return 0, err
+		}
+
 		fuel := 0
 		for pos, count := range crabCountByPosition {
 			fuel += count * fuelSpent(abs(pos-i))
@@ -44,7 +77,7 @@ func alignCrabs(crabCountByPosition map[int]int) int {
 		minFuel = min(minFuel, fuel)
 	}
 
-	return minFuel
+	return minFuel, nil
 }
 
 func minMaxPosition(crabCountByPosition map[int]int) (int, int) {
@@ -78,6 +111,24 @@ func fuelSpent(distance int) int {
 	return distance * (distance + 1) / 2
 }
 
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	crabPositions, err := loadCrabPositions(filepath)
+	if err != nil {
+		return err
+	}
+
+	min, max := inspect.Range(crabPositions)
+	inspect.Print("lines", lineCount)
+	inspect.Print("crabs", len(crabPositions))
+	inspect.Print("position range", fmt.Sprintf("%d..%d", min, max))
+	return nil
+}
+
 func loadCrabPositions(filepath string) ([]int, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
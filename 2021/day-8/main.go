@@ -2,9 +2,17 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"os"
 	"strings"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
+	"github.com/skhalash/adventofcode/internal/trace"
 )
 
 type task struct {
@@ -55,8 +63,37 @@ func (p pattern) equals(other pattern) bool {
 	return len(p) == len(other) && p.contains(other)
 }
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	newTracer := trace.Flags()
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	tracer, err := newTracer()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 8, Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache && !tracer.Enabled(), func() (interface{}, error) {
+		return run(ctx, flag.Arg(0), tracer)
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -65,16 +102,25 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string, tracer *trace.Tracer) (int, error) {
 	tasks, err := loadTasks(filepath)
 	if err != nil {
 		return 0, err
 	}
 
 	sum := 0
-	for _, task := range tasks {
+	for i, task := range tasks {
+		if err := timeout.Check(ctx); err != nil {
+			return 0, err
+		}
+
 		patternByDigit := deduceDigits(task.patterns)
-		sum += decodeOutput(task.output, patternByDigit)
+		output := decodeOutput(task.output, patternByDigit)
+		tracer.Event("deduce",
+			trace.F("entry", i+1),
+			trace.F("digits", patternByDigit),
+			trace.F("output", output))
+		sum += output
 	}
 
 	return sum, nil
@@ -126,6 +172,22 @@ func decodeOutput(output []pattern, patternByDigit []pattern) int {
 	return result
 }
 
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	tasks, err := loadTasks(filepath)
+	if err != nil {
+		return err
+	}
+
+	inspect.Print("lines", lineCount)
+	inspect.Print("entries", len(tasks))
+	return nil
+}
+
 func loadTasks(filepath string) ([]task, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
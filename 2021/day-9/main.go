@@ -2,10 +2,17 @@ package main
 
 import (
 	"bufio"
+	"context"
+	"embed"
+	"flag"
 	"fmt"
 	"os"
 	"sort"
 	"strconv"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+	"github.com/skhalash/adventofcode/internal/inspect"
+	"github.com/skhalash/adventofcode/internal/timeout"
 )
 
 type point struct {
@@ -13,8 +20,30 @@ type point struct {
 	height int
 }
 
+//go:embed *.go
+var source embed.FS
+
 func main() {
-	result, err := run(os.Args[1])
+	noCache := cache.Flag()
+	timeoutAfter := timeout.Flag()
+	inspectOnly := inspect.Flag()
+	flag.Parse()
+
+	if *inspectOnly {
+		if err := summarize(flag.Arg(0)); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		return
+	}
+
+	ctx, cancel := timeout.Context(*timeoutAfter)
+	defer cancel()
+
+	puzzle := cache.Puzzle{Year: 2021, Day: 9, Source: source}
+	result, err := cache.Solve(puzzle, flag.Arg(0), !*noCache, func() (interface{}, error) {
+		return run(ctx, flag.Arg(0))
+	})
 	if err != nil {
 		fmt.Fprintln(os.Stderr, err)
 		os.Exit(2)
@@ -23,7 +52,7 @@ func main() {
 	fmt.Println(result)
 }
 
-func run(filepath string) (int, error) {
+func run(ctx context.Context, filepath string) (int, error) {
 	heightmap, err := loadHeightmap(filepath)
 	if err != nil {
 		return 0, err
@@ -31,6 +60,10 @@ func run(filepath string) (int, error) {
 
 	var basinSizes []int
 	for _, pt := range lowPoints(heightmap) {
+		if err := timeout.Check(ctx); err != nil {
+			return 0, err
+		}
+
 		basinSizes = append(basinSizes, basin(pt, heightmap))
 	}
 
@@ -100,6 +133,27 @@ func neighbours(pt point, heightmap [][]int) []point {
 	return neighbours
 }
 
+func summarize(filepath string) error {
+	lineCount, err := inspect.LineCount(filepath)
+	if err != nil {
+		return err
+	}
+
+	heightmap, err := loadHeightmap(filepath)
+	if err != nil {
+		return err
+	}
+
+	columns := 0
+	if len(heightmap) > 0 {
+		columns = len(heightmap[0])
+	}
+
+	inspect.Print("lines", lineCount)
+	inspect.Print("grid", fmt.Sprintf("%dx%d", len(heightmap), columns))
+	return nil
+}
+
 func loadHeightmap(filepath string) ([][]int, error) {
 	file, err := os.Open(filepath)
 	if err != nil {
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+
+	"github.com/skhalash/adventofcode/internal/cache"
+)
+
+const usage = `usage:
+  aoc inspect <year> <day>    summarize the day's parsed input
+  aoc cache clear             remove all cached answers`
+
+func main() {
+	if err := run(os.Args[1:]); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+}
+
+func run(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(usage)
+	}
+
+	switch args[0] {
+	case "inspect":
+		return runInspect(args[1:])
+	case "cache":
+		return runCache(args[1:])
+	}
+
+	return fmt.Errorf("unknown command %s\n%s", args[0], usage)
+}
+
+func runInspect(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf(usage)
+	}
+
+	dir, err := dayDir(args[0], args[1])
+	if err != nil {
+		return err
+	}
+
+	cmd := exec.Command("go", "run", "./"+dir, "-inspect", filepath.Join(dir, "input.txt"))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+func dayDir(year, day string) (string, error) {
+	if _, err := strconv.Atoi(year); err != nil {
+		return "", fmt.Errorf("invalid year %s", year)
+	}
+	if _, err := strconv.Atoi(day); err != nil {
+		return "", fmt.Errorf("invalid day %s", day)
+	}
+
+	dir := filepath.Join(year, "day-"+day)
+	if _, err := os.Stat(dir); err != nil {
+		return "", fmt.Errorf("no solver for %s day %s", year, day)
+	}
+	return dir, nil
+}
+
+func runCache(args []string) error {
+	if len(args) != 1 || args[0] != "clear" {
+		return fmt.Errorf(usage)
+	}
+
+	return cache.Clear()
+}
@@ -0,0 +1,230 @@
+// Package cache stores puzzle answers on disk so that rerunning an unchanged
+// solver on an unchanged input returns instantly.
+package cache
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Key identifies a cached answer. Part distinguishes answers computed from
+// the same input, e.g. by solver parameters.
+type Key struct {
+	Year       int
+	Day        int
+	Part       string
+	InputHash  string
+	SourceHash string
+}
+
+// Puzzle describes the solver whose answers are cached.
+type Puzzle struct {
+	Year   int
+	Day    int
+	Part   string
+	Source fs.FS
+}
+
+// Flag registers -no-cache on the default flag set.
+func Flag() *bool {
+	return flag.Bool("no-cache", false, "always solve, ignoring and not updating the answer cache")
+}
+
+// Dir returns the directory holding cached answers.
+func Dir() (string, error) {
+	base, err := os.UserCacheDir()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate cache directory: %v", err)
+	}
+	return filepath.Join(base, "adventofcode"), nil
+}
+
+// Clear removes all cached answers.
+func Clear() error {
+	dir, err := Dir()
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(dir); err != nil {
+		return fmt.Errorf("failed to clear cache: %v", err)
+	}
+	return nil
+}
+
+// Solve returns the cached answer for the puzzle input if there is one.
+// Otherwise it calls solve and, if enabled, stores the answer for later runs.
+// The cache is only an optimization, so failing to use it is reported as a
+// warning and the puzzle is solved anyway.
+func Solve(p Puzzle, inputPath string, enabled bool, solve func() (interface{}, error)) (string, error) {
+	if !enabled {
+		return solveString(solve)
+	}
+
+	key, err := newKey(p, inputPath)
+	if err != nil {
+		warn(err)
+		return solveString(solve)
+	}
+
+	if answer, found, err := get(key); err != nil {
+		warn(err)
+		return solveString(solve)
+	} else if found {
+		return answer, nil
+	}
+
+	answer, err := solveString(solve)
+	if err != nil {
+		return "", err
+	}
+
+	if err := put(key, answer); err != nil {
+		warn(err)
+	}
+
+	return answer, nil
+}
+
+func warn(err error) {
+	fmt.Fprintf(os.Stderr, "warning: answer cache disabled: %v\n", err)
+}
+
+func solveString(solve func() (interface{}, error)) (string, error) {
+	result, err := solve()
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprint(result), nil
+}
+
+func newKey(p Puzzle, inputPath string) (Key, error) {
+	inputHash, err := HashFile(inputPath)
+	if err != nil {
+		return Key{}, err
+	}
+
+	sourceHash, err := HashFS(p.Source)
+	if err != nil {
+		return Key{}, err
+	}
+
+	return Key{
+		Year:       p.Year,
+		Day:        p.Day,
+		Part:       p.Part,
+		InputHash:  inputHash,
+		SourceHash: sourceHash,
+	}, nil
+}
+
+func get(key Key) (string, bool, error) {
+	path, err := entryPath(key)
+	if err != nil {
+		return "", false, err
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", false, nil
+	}
+	if err != nil {
+		return "", false, fmt.Errorf("failed to read cached answer: %v", err)
+	}
+
+	return string(data), true, nil
+}
+
+func put(key Key, answer string) error {
+	path, err := entryPath(key)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create cache directory: %v", err)
+	}
+
+	// Write to a temporary file first and rename it into place, so that
+	// concurrent runs never read a partially written answer.
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return fmt.Errorf("failed to write cached answer: %v", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.WriteString(answer); err != nil {
+		tmp.Close()
+		return fmt.Errorf("failed to write cached answer: %v", err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("failed to write cached answer: %v", err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write cached answer: %v", err)
+	}
+	return nil
+}
+
+func entryPath(key Key) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+
+	h := sha256.New()
+	fmt.Fprintf(h, "%q\n%q\n%q\n", key.Part, key.InputHash, key.SourceHash)
+	name := hex.EncodeToString(h.Sum(nil))
+
+	return filepath.Join(dir, fmt.Sprint(key.Year), fmt.Sprintf("day-%d", key.Day), name), nil
+}
+
+// HashFile returns the hex encoded SHA-256 of the file content.
+func HashFile(path string) (string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", fmt.Errorf("failed to open data file: %v", err)
+	}
+	defer file.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, file); err != nil {
+		return "", fmt.Errorf("failed to hash data file: %v", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// HashFS returns the hex encoded SHA-256 of all file names and contents in
+// fsys. Test files cannot change an answer and are left out.
+func HashFS(fsys fs.FS) (string, error) {
+	h := sha256.New()
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || strings.HasSuffix(path, "_test.go") {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(h, "%s\n%d\n", path, len(data))
+		h.Write(data)
+		return nil
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to hash solver source: %v", err)
+	}
+
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,59 @@
+// Package inspect helps solvers summarize a parsed input instead of solving
+// it, which makes malformed inputs easier to spot.
+package inspect
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// Flag registers -inspect on the default flag set.
+func Flag() *bool {
+	return flag.Bool("inspect", false, "print a summary of the parsed input instead of solving")
+}
+
+// LineCount returns the number of lines in the file.
+func LineCount(filepath string) (int, error) {
+	file, err := os.Open(filepath)
+	if err != nil {
+		return 0, fmt.Errorf("failed to open data file: %v", err)
+	}
+	defer file.Close()
+
+	count := 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<24)
+	for scanner.Scan() {
+		count++
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, fmt.Errorf("failed to read data file: %v", err)
+	}
+	return count, nil
+}
+
+// Range returns the smallest and the largest of values, or zeros if there are none.
+func Range(values []int) (int, int) {
+	if len(values) == 0 {
+		return 0, 0
+	}
+
+	min, max := values[0], values[0]
+	for _, v := range values {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+// Print writes a single summary line.
+func Print(name string, value interface{}) {
+	fmt.Printf("%s: %v\n", name, value)
+}
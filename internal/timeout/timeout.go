@@ -0,0 +1,38 @@
+// Package timeout bounds how long a solver may run.
+package timeout
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"time"
+)
+
+var ErrTimeout = errors.New("solver timed out")
+
+// Flag registers -timeout on the default flag set.
+func Flag() *time.Duration {
+	return flag.Duration("timeout", 0, "abort solving after the given duration (no limit if 0)")
+}
+
+// Context returns a context that expires after d, or never if d is zero.
+func Context(d time.Duration) (context.Context, context.CancelFunc) {
+	if d == 0 {
+		return context.WithCancel(context.Background())
+	}
+	return context.WithTimeout(context.Background(), d)
+}
+
+// Check returns nil while ctx is active. Once it expires, it returns
+// ErrTimeout, so solvers can call it from their hot loops and bail out.
+func Check(ctx context.Context) error {
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return ErrTimeout
+		}
+		return ctx.Err()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,109 @@
+// Package trace lets solvers emit structured events describing how an answer
+// was derived, either as plain text or as JSON lines.
+package trace
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+type Format string
+
+var Text Format = "text"
+var JSON Format = "json"
+
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// F is a shorthand for building an event field.
+func F(key string, value interface{}) Field {
+	return Field{Key: key, Value: value}
+}
+
+// Tracer writes events to an underlying writer. A nil *Tracer is valid and
+// discards all events, so solvers can trace unconditionally.
+type Tracer struct {
+	w      io.Writer
+	format Format
+}
+
+func New(w io.Writer, format Format) (*Tracer, error) {
+	switch format {
+	case Text, JSON:
+	default:
+		return nil, fmt.Errorf("unknown trace format: %s", format)
+	}
+
+	return &Tracer{w: w, format: format}, nil
+}
+
+// Flags registers -trace and -trace-format on the default flag set. The
+// returned function must be called after flag.Parse and yields a tracer
+// writing to stderr, or nil if tracing is disabled.
+func Flags() func() (*Tracer, error) {
+	enabled := flag.Bool("trace", false, "emit solver events to stderr")
+	format := flag.String("trace-format", string(Text), "trace event format (text or json)")
+
+	return func() (*Tracer, error) {
+		if !*enabled {
+			return nil, nil
+		}
+		return New(os.Stderr, Format(*format))
+	}
+}
+
+func (t *Tracer) Enabled() bool {
+	return t != nil
+}
+
+func (t *Tracer) Event(name string, fields ...Field) {
+	if t == nil {
+		return
+	}
+
+	var line string
+	if t.format == JSON {
+		line = jsonLine(name, fields)
+	} else {
+		line = textLine(name, fields)
+	}
+
+	fmt.Fprintln(t.w, line)
+}
+
+func textLine(name string, fields []Field) string {
+	var sb strings.Builder
+	sb.WriteString(name)
+	for _, f := range fields {
+		fmt.Fprintf(&sb, " %s=%v", f.Key, f.Value)
+	}
+	return sb.String()
+}
+
+func jsonLine(name string, fields []Field) string {
+	var sb strings.Builder
+	sb.WriteString(`{"event":`)
+	sb.Write(marshal(name))
+	for _, f := range fields {
+		sb.WriteString(",")
+		sb.Write(marshal(f.Key))
+		sb.WriteString(":")
+		sb.Write(marshal(f.Value))
+	}
+	sb.WriteString("}")
+	return sb.String()
+}
+
+func marshal(v interface{}) []byte {
+	data, err := json.Marshal(v)
+	if err != nil {
+		data, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return data
+}
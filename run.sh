@@ -2,6 +2,6 @@
 
 for dir in ./2021/*/ ; do
     echo "$dir"
-    go run "${dir}main.go" "${dir}input.txt"
+    go run "$dir" "${dir}input.txt"
     echo ""
 done